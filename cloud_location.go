@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ResourceLocation describes the region and account a resource is deployed into.
+// Account holds the AWS account, Azure subscription or GCP project.
+type ResourceLocation struct {
+	Region  string
+	Account string
+}
+
+// deriveResourceLocation works out the region and account of a resource from its
+// ARNs, Azure resource IDs, GCP self-links and location attributes, falling back to
+// the state-wide provider defaults
+func deriveResourceLocation(resource Resource, defaults map[string]ResourceLocation) ResourceLocation {
+	location := ResourceLocation{}
+
+	// The resource's own identifiers are the most reliable source, others it references come next
+	if arn, ok := resource.Values["arn"].(string); ok && strings.HasPrefix(arn, "arn:") {
+		location = locationFromARN(arn)
+		// Global services such as IAM have no region in their ARNs
+		if location.Region == "" {
+			location.Region = "global"
+		}
+	}
+	for _, key := range []string{"id", "self_link"} {
+		if value, ok := resource.Values[key].(string); ok {
+			fillLocation(&location, locationFromResourceID(value))
+		}
+	}
+	if location.Region == "" || location.Account == "" {
+		keys := make([]string, 0, len(resource.Values))
+		for key := range resource.Values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			value, ok := resource.Values[key].(string)
+			if !ok {
+				continue
+			}
+			if strings.HasPrefix(value, "arn:") {
+				fillLocation(&location, locationFromARN(value))
+			} else if strings.HasSuffix(key, "_id") || strings.HasSuffix(key, "self_link") {
+				fillLocation(&location, locationFromResourceID(value))
+			}
+		}
+	}
+
+	// Explicit location attributes
+	for _, key := range []string{"region", "location"} {
+		if value, ok := resource.Values[key].(string); ok && value != "" && location.Region == "" {
+			location.Region = value
+		}
+	}
+	for _, key := range []string{"availability_zone", "zone"} {
+		if zone, ok := resource.Values[key].(string); ok && location.Region == "" && len(zone) > 1 {
+			location.Region = regionFromZone(zone)
+		}
+	}
+	for _, key := range []string{"owner_id", "account_id", "project", "subscription_id"} {
+		if value, ok := resource.Values[key].(string); ok && value != "" && location.Account == "" {
+			location.Account = value
+		}
+	}
+
+	fillLocation(&location, defaults[providerShortName(resource.ProviderName)])
+	return location
+}
+
+// locationFromARN extracts the region and account from an ARN
+// (arn:partition:service:region:account:resource)
+func locationFromARN(arn string) ResourceLocation {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) < 6 || parts[0] != "arn" {
+		return ResourceLocation{}
+	}
+	return ResourceLocation{Region: parts[3], Account: parts[4]}
+}
+
+// locationFromResourceID extracts the subscription from an Azure resource ID
+// (/subscriptions/<id>/resourceGroups/...) or the project and region from a GCP
+// resource path or self-link (.../projects/<project>/regions/<region>/...)
+func locationFromResourceID(id string) ResourceLocation {
+	location := ResourceLocation{}
+	segments := strings.Split(strings.Trim(id, "/"), "/")
+	if len(segments) < 2 {
+		return location
+	}
+
+	if strings.HasPrefix(strings.ToLower(id), "/subscriptions/") {
+		location.Account = segments[1]
+		return location
+	}
+
+	for i := 0; i+1 < len(segments); i++ {
+		switch segments[i] {
+		case "projects":
+			if location.Account == "" {
+				location.Account = segments[i+1]
+			}
+		case "regions", "locations":
+			if location.Account != "" && location.Region == "" {
+				location.Region = segments[i+1]
+			}
+		case "zones":
+			if location.Account != "" && location.Region == "" {
+				location.Region = regionFromZone(segments[i+1])
+			}
+		}
+	}
+
+	return location
+}
+
+// regionFromZone returns the region an availability zone belongs to
+// (us-east-1a becomes us-east-1, us-central1-b becomes us-central1)
+func regionFromZone(zone string) string {
+	// GCP zones end in "-<letter>"
+	if index := strings.LastIndex(zone, "-"); index > 0 && len(zone)-index == 2 {
+		return zone[:index]
+	}
+	return strings.TrimRight(zone, "abcdefghijklmnopqrstuvwxyz")
+}
+
+// fillLocation copies fields from source into location where location is still empty
+func fillLocation(location *ResourceLocation, source ResourceLocation) {
+	if location.Region == "" {
+		location.Region = source.Region
+	}
+	if location.Account == "" {
+		location.Account = source.Account
+	}
+}
+
+// stateLocationDefaults reads provider-level region and account defaults from
+// data sources such as data.aws_region, data.aws_caller_identity,
+// data.azurerm_client_config and data.google_client_config
+func stateLocationDefaults(stateData *StateData) map[string]ResourceLocation {
+	defaults := make(map[string]ResourceLocation)
+
+	for _, resource := range stateData.Resources {
+		if resource.Mode != "data" {
+			continue
+		}
+
+		provider := providerShortName(resource.ProviderName)
+		location := defaults[provider]
+
+		var regionKeys, accountKeys []string
+		switch resource.Type {
+		case "aws_region":
+			regionKeys = []string{"name", "region"}
+		case "aws_caller_identity":
+			accountKeys = []string{"account_id"}
+		case "azurerm_client_config", "azurerm_subscription":
+			accountKeys = []string{"subscription_id"}
+		case "google_client_config":
+			regionKeys = []string{"region"}
+			accountKeys = []string{"project"}
+		case "google_project":
+			accountKeys = []string{"project_id"}
+		default:
+			continue
+		}
+
+		for _, key := range regionKeys {
+			if value, ok := resource.Values[key].(string); ok && value != "" && location.Region == "" {
+				location.Region = value
+			}
+		}
+		for _, key := range accountKeys {
+			if value, ok := resource.Values[key].(string); ok && value != "" && location.Account == "" {
+				location.Account = value
+			}
+		}
+
+		defaults[provider] = location
+	}
+
+	return defaults
+}
+
+// assignResourceLocations sets the region and account of every resource in the state
+func assignResourceLocations(state *StateData) {
+	defaults := stateLocationDefaults(state)
+
+	locate := func(resources []Resource) {
+		for i := range resources {
+			location := deriveResourceLocation(resources[i], defaults)
+			resources[i].Region = location.Region
+			resources[i].Account = location.Account
+		}
+	}
+
+	locate(state.Resources)
+	locate(state.RootModule.Resources)
+	// Modules are passed by value but share their resource slices with the state tree
+	walkModules(state.RootModule.ChildModules, func(module Module, parent string) {
+		locate(module.Resources)
+	})
+}
+
+// LocationGroup counts the resources of one account, broken down by region
+type LocationGroup struct {
+	Account string
+	Total   int
+	Regions []RegionGroup
+}
+
+// RegionGroup lists the resources deployed into one region
+type RegionGroup struct {
+	Region    string
+	Addresses []string
+}
+
+// groupResourcesByLocation groups managed resources by account and region,
+// ordering accounts and regions by resource count
+func groupResourcesByLocation(stateData *StateData) []LocationGroup {
+	byAccount := make(map[string]map[string][]string)
+
+	for _, resource := range stateData.Resources {
+		if resource.Mode == "data" {
+			continue
+		}
+		account := resource.Account
+		if account == "" {
+			account = "unknown"
+		}
+		region := resource.Region
+		if region == "" {
+			region = "unknown"
+		}
+		if byAccount[account] == nil {
+			byAccount[account] = make(map[string][]string)
+		}
+		byAccount[account][region] = append(byAccount[account][region], resource.Address)
+	}
+
+	var groups []LocationGroup
+	for account, regions := range byAccount {
+		group := LocationGroup{Account: account}
+		for region, addresses := range regions {
+			group.Regions = append(group.Regions, RegionGroup{Region: region, Addresses: addresses})
+			group.Total += len(addresses)
+		}
+		sort.Slice(group.Regions, func(i, j int) bool {
+			if len(group.Regions[i].Addresses) != len(group.Regions[j].Addresses) {
+				return len(group.Regions[i].Addresses) > len(group.Regions[j].Addresses)
+			}
+			return group.Regions[i].Region < group.Regions[j].Region
+		})
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Total != groups[j].Total {
+			return groups[i].Total > groups[j].Total
+		}
+		return groups[i].Account < groups[j].Account
+	})
+
+	return groups
+}
+
+// generateLocationHtml renders the resources by account and region section of the state overview.
+// Regions holding only a few of an account's resources are highlighted as possible strays.
+func generateLocationHtml(groups []LocationGroup) string {
+	if len(groups) == 0 || (len(groups) == 1 && groups[0].Account == "unknown" &&
+		len(groups[0].Regions) == 1 && groups[0].Regions[0].Region == "unknown") {
+		return ""
+	}
+
+	var html strings.Builder
+	html.WriteString(`<div style="margin-top: 20px;">
+			<h3>Resources by Account and Region</h3>`)
+
+	for _, group := range groups {
+		html.WriteString(fmt.Sprintf(`
+			<h4>%s <span style="color: var(--muted); font-weight: normal;">(%d resources)</span></h4>
+			<div style="display: flex; flex-wrap: wrap; gap: 10px; margin-top: 10px;">`,
+			escapeHtml(group.Account), group.Total))
+
+		for _, region := range group.Regions {
+			border := "var(--accent)"
+			// A region holding less than a tenth of a multi-region account is worth a second look
+			if len(group.Regions) > 1 && len(region.Addresses)*10 < group.Total {
+				border = "var(--warn)"
+			}
+
+			html.WriteString(fmt.Sprintf(`
+				<details style="border: 1px solid var(--border); border-left: 4px solid %s; border-radius: 4px; padding: 8px 12px; min-width: 180px;">
+					<summary style="cursor: pointer;"><span style="font-weight: bold; color: var(--heading);">%s</span>
+					<span style="color: %s; margin-left: 10px;">%d</span></summary>`,
+				border, escapeHtml(region.Region), border, len(region.Addresses)))
+			for _, address := range region.Addresses {
+				html.WriteString(`<div style="font-family: monospace; font-size: 0.85em; padding: 2px 0;">` + escapeHtml(address) + `</div>`)
+			}
+			html.WriteString(`</details>`)
+		}
+
+		html.WriteString(`</div>`)
+	}
+
+	html.WriteString(`</div>`)
+	return html.String()
+}
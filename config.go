@@ -0,0 +1,351 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configFileName is the configuration file looked up in the working directory and
+// its parents up to the repository root
+const configFileName = ".tfviz.yaml"
+
+// Config holds the default options read from a configuration file; command line
+// flags override the values set here
+type Config struct {
+	Format           string              `yaml:"format"`
+	GraphLevel       string              `yaml:"graph_level"`
+	Columns          []string            `yaml:"columns,omitempty"`
+	Redaction        string              `yaml:"redaction"`
+	SensitiveKeys    []string            `yaml:"sensitive_keys"`
+	NonSensitiveKeys []string            `yaml:"non_sensitive_keys,omitempty"`
+	RequiredTags     []string            `yaml:"required_tags,omitempty"`
+	AllowedTagValues map[string][]string `yaml:"allowed_tag_values,omitempty"`
+	Ignore           IgnoreConfig        `yaml:"ignore,omitempty"`
+	Filter           ResourceFilter      `yaml:"filter,omitempty"`
+	Cost             bool                `yaml:"cost,omitempty"`
+	Pricing          string              `yaml:"pricing,omitempty"`
+	ConsoleLinks     string              `yaml:"console_links,omitempty"`
+	Schema           string              `yaml:"schema,omitempty"`
+	Theme            string              `yaml:"theme"`
+	Title            string              `yaml:"title,omitempty"`
+	Logo             string              `yaml:"logo,omitempty"`
+	CSS              string              `yaml:"css,omitempty"`
+	Footer           string              `yaml:"footer,omitempty"`
+	NoPromo          bool                `yaml:"no_promo,omitempty"`
+	Template         string              `yaml:"template,omitempty"`
+
+	// Path is the configuration file the values were read from, if any
+	Path string `yaml:"-"`
+}
+
+// IgnoreConfig lists resources left out of every output
+type IgnoreConfig struct {
+	// Types are resource types to drop, e.g. aws_iam_role_policy_attachment
+	Types []string `yaml:"types,omitempty"`
+	// Addresses are resource address globs to drop, e.g. module.legacy.*
+	Addresses []string `yaml:"addresses,omitempty"`
+}
+
+// defaultConfig returns the configuration used when no file or flag sets a value
+func defaultConfig() Config {
+	return Config{
+		Format:        formatHTML,
+		GraphLevel:    graphLevelResource,
+		Redaction:     redactionPartial,
+		SensitiveKeys: append([]string{}, defaultSensitiveKeys...),
+		Theme:         themeAuto,
+	}
+}
+
+// findConfigFile returns the explicit config path, or the nearest .tfviz.yaml in the
+// working directory or its parents up to the repository root; "" means no file
+func findConfigFile(explicit string) (string, error) {
+	if explicit != "" {
+		return explicit, nil
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", nil
+	}
+	for {
+		candidate := filepath.Join(dir, configFileName)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+		// Stop at the repository root
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// loadConfig reads the configuration file on top of the defaults. File paths in the
+// configuration are relative to the file's directory.
+func loadConfig(explicitPath string) (Config, error) {
+	config := defaultConfig()
+
+	path, err := findConfigFile(explicitPath)
+	if err != nil || path == "" {
+		return config, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("failed to read config file %s: %v", path, err)
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("parsing config file %s: %v", path, err)
+	}
+	config.Path = path
+
+	base := filepath.Dir(path)
+	for _, file := range []*string{&config.Pricing, &config.ConsoleLinks, &config.Schema, &config.CSS, &config.Template} {
+		if *file != "" && !filepath.IsAbs(*file) {
+			*file = filepath.Join(base, *file)
+		}
+	}
+	if config.Logo != "" && !isRemoteLogo(config.Logo) && !filepath.IsAbs(config.Logo) {
+		config.Logo = filepath.Join(base, config.Logo)
+	}
+
+	return config, nil
+}
+
+// defineConfigFlag registers the -config flag selecting the configuration file
+func defineConfigFlag(flags *flag.FlagSet) *string {
+	return flags.String("config", "", "Configuration file (default: nearest .tfviz.yaml)")
+}
+
+// defineSubcommandFlags registers the configuration flags the subcommands share
+func defineSubcommandFlags(flags *flag.FlagSet) *string {
+	configPath := defineConfigFlag(flags)
+	flags.String("schema", "", "Provider schema JSON from terraform providers schema -json")
+	return configPath
+}
+
+// defineConfigFlags registers the flags that override configuration values
+func defineConfigFlags(flags *flag.FlagSet) *string {
+	configPath := defineConfigFlag(flags)
+	flags.String("format", formatHTML, "Output format: html, dot, mermaid, svg, text, table, csv, xlsx")
+	flags.String("graph-level", graphLevelResource, "Graph granularity for dot/mermaid/svg output: resource, type, module")
+	flags.String("columns", "", "Comma-separated attribute columns for csv/xlsx output (e.g. tags.CostCenter)")
+	flags.String("redaction", redactionPartial, "Sensitive value redaction: partial, full, none")
+	flags.String("required-tags", "", "Comma-separated tags every taggable resource must have (adds a Tag Compliance section)")
+	flags.String("allowed-tag-values", "", "Allowed tag values, e.g. Environment=dev|stage|prod")
+	flags.String("include-type", "", "Only render these resource types (comma-separated globs, e.g. aws_iam_*)")
+	flags.String("exclude-type", "", "Leave out these resource types (comma-separated globs)")
+	flags.String("module", "", "Only render resources in these modules and their children (comma-separated; root for the root module)")
+	flags.String("include-address", "", "Only render these resource addresses (comma-separated globs, or /regex/)")
+	flags.Bool("no-data-sources", false, "Leave out data sources")
+	flags.Bool("cost", false, "Add an estimated monthly cost section using the built-in pricing catalog")
+	flags.String("pricing", "", "Pricing catalog JSON file for cost estimates (implies -cost)")
+	flags.String("console-links", "", "JSON file with extra console link templates for the HTML page")
+	flags.String("schema", "", "Provider schema JSON file from terraform providers schema -json")
+	flags.String("theme", themeAuto, "HTML theme: auto, dark, high-contrast, light")
+	flags.String("css", "", "CSS file to include after the theme styles")
+	flags.String("logo", "", "Logo image file or URL shown next to the page title")
+	flags.String("title", "", "Page title (default: Terraform State)")
+	flags.String("footer", "", "Footer text shown at the bottom of the page")
+	flags.Bool("no-promo", false, "Omit the CloudVIC promo message from the HTML page")
+	flags.String("template", "", "Directory with custom page templates (see: template export)")
+	return configPath
+}
+
+// loadConfigWithFlags loads the configuration file and applies the flags that were
+// explicitly set on the command line
+func loadConfigWithFlags(flags *flag.FlagSet, configPath string) (Config, error) {
+	config, err := loadConfig(configPath)
+	if err != nil {
+		return config, err
+	}
+
+	var flagErr error
+	flags.Visit(func(f *flag.Flag) {
+		if err := config.applyFlag(f.Name, f.Value.String()); err != nil && flagErr == nil {
+			flagErr = err
+		}
+	})
+	return config, flagErr
+}
+
+// applyFlag overrides a configuration value with a command line flag value
+func (c *Config) applyFlag(name, value string) error {
+	var err error
+	switch name {
+	case "format":
+		c.Format = value
+	case "graph-level":
+		c.GraphLevel = value
+	case "columns":
+		c.Columns = splitList(value)
+	case "redaction":
+		c.Redaction = value
+	case "required-tags":
+		c.RequiredTags = splitList(value)
+	case "allowed-tag-values":
+		c.AllowedTagValues, err = parseAllowedTagValues(value)
+	case "include-type":
+		c.Filter.IncludeTypes = splitList(value)
+	case "exclude-type":
+		c.Filter.ExcludeTypes = splitList(value)
+	case "module":
+		c.Filter.Modules = splitList(value)
+	case "include-address":
+		c.Filter.IncludeAddresses = splitList(value)
+	case "no-data-sources":
+		c.Filter.NoDataSources, err = strconv.ParseBool(value)
+	case "cost":
+		c.Cost, err = strconv.ParseBool(value)
+	case "pricing":
+		c.Pricing = value
+	case "console-links":
+		c.ConsoleLinks = value
+	case "schema":
+		c.Schema = value
+	case "theme":
+		c.Theme = value
+	case "css":
+		c.CSS = value
+	case "logo":
+		c.Logo = value
+	case "title":
+		c.Title = value
+	case "footer":
+		c.Footer = value
+	case "no-promo":
+		c.NoPromo, err = strconv.ParseBool(value)
+	case "template":
+		c.Template = value
+	}
+	return err
+}
+
+// buildOptions turns the effective configuration into rendering options, loading
+// the pricing catalog, console links and branding files it references
+func buildOptions(config Config) (Options, error) {
+	if err := configureRedaction(config.Redaction, config.SensitiveKeys, config.NonSensitiveKeys); err != nil {
+		return Options{}, err
+	}
+
+	options := Options{
+		Format:      config.Format,
+		GraphLevel:  config.GraphLevel,
+		Columns:     config.Columns,
+		TagPolicy:   TagPolicy{Required: config.RequiredTags, Allowed: config.AllowedTagValues},
+		Filter:      config.resourceFilter(),
+		Theme:       config.Theme,
+		TemplateDir: config.Template,
+	}
+
+	if config.Cost || config.Pricing != "" {
+		catalog, err := loadPricingCatalog(config.Pricing)
+		if err != nil {
+			return Options{}, err
+		}
+		options.PricingCatalog = catalog
+	}
+
+	consoleLinks, err := loadConsoleLinks(config.ConsoleLinks)
+	if err != nil {
+		return Options{}, err
+	}
+	options.ConsoleLinks = consoleLinks
+
+	options.Schemas, err = loadProviderSchemas(config.Schema)
+	if err != nil {
+		return Options{}, err
+	}
+
+	options.Branding, err = loadBranding(config.Title, config.Logo, config.CSS, config.Footer, config.NoPromo)
+	if err != nil {
+		return Options{}, err
+	}
+
+	return options, nil
+}
+
+// loadSubcommandOptions loads the configuration of a subcommand with its flags overriding the
+// file, applies its redaction settings and returns the options the subcommands share: the
+// ignore lists, the provider schemas, the tag policy and the pricing catalog when one is configured
+func loadSubcommandOptions(flags *flag.FlagSet, configPath string) (Options, error) {
+	config, err := loadConfigWithFlags(flags, configPath)
+	if err != nil {
+		return Options{}, err
+	}
+	if err := configureRedaction(config.Redaction, config.SensitiveKeys, config.NonSensitiveKeys); err != nil {
+		return Options{}, err
+	}
+
+	schemas, err := loadProviderSchemas(config.Schema)
+	if err != nil {
+		return Options{}, err
+	}
+	options := Options{
+		Filter:    config.ignoreFilter(),
+		Schemas:   schemas,
+		TagPolicy: TagPolicy{Required: config.RequiredTags, Allowed: config.AllowedTagValues},
+	}
+
+	if config.Pricing != "" {
+		catalog, err := loadPricingCatalog(config.Pricing)
+		if err != nil {
+			return Options{}, err
+		}
+		options.PricingCatalog = catalog
+	}
+	return options, nil
+}
+
+// ignoreFilter returns the filter leaving out the ignored resources
+func (c Config) ignoreFilter() ResourceFilter {
+	return ResourceFilter{ExcludeTypes: c.Ignore.Types, ExcludeAddresses: c.Ignore.Addresses}
+}
+
+// resourceFilter combines the filter settings with the ignore lists
+func (c Config) resourceFilter() ResourceFilter {
+	filter := c.Filter
+	filter.ExcludeTypes = append(append([]string{}, filter.ExcludeTypes...), c.Ignore.Types...)
+	filter.ExcludeAddresses = append(append([]string{}, filter.ExcludeAddresses...), c.Ignore.Addresses...)
+	return filter
+}
+
+// runConfigCommand runs the "config" subcommand
+func runConfigCommand(args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return errors.New("usage: terraform-state-visualizer config print [-config <file>] [options]")
+	}
+
+	flags := flag.NewFlagSet("config print", flag.ExitOnError)
+	configPath := defineConfigFlags(flags)
+	flags.Parse(args[1:])
+
+	config, err := loadConfigWithFlags(flags, *configPath)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("encoding config: %v", err)
+	}
+
+	if config.Path != "" {
+		fmt.Printf("# Effective configuration (file: %s)\n", config.Path)
+	} else {
+		fmt.Println("# Effective configuration (no config file found)")
+	}
+	fmt.Print(string(data))
+	return nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// builtinConsoleLinks is the console link table shipped with the binary
+//
+//go:embed console_links.json
+var builtinConsoleLinks []byte
+
+// ConsoleLinkTable maps resource types to cloud console URL templates.
+//
+// Templates reference resource attributes as {path} (e.g. {id}, {metadata[0].name}),
+// the derived {region} and {account}, and table variables such as {kubernetes_dashboard}.
+// Attribute values are URL-escaped unless written as {path:raw}. Keys ending in "*"
+// match every type with that prefix.
+type ConsoleLinkTable struct {
+	Variables map[string]string `json:"variables"`
+	Links     map[string]string `json:"links"`
+}
+
+// loadConsoleLinks loads the built-in console link table and merges the entries
+// from path over it; an empty template in the file removes a built-in link
+func loadConsoleLinks(path string) (*ConsoleLinkTable, error) {
+	table := &ConsoleLinkTable{}
+	if err := json.Unmarshal(builtinConsoleLinks, table); err != nil {
+		return nil, fmt.Errorf("parsing built-in console links: %v", err)
+	}
+	if path == "" {
+		return table, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read console links %s: %v", path, err)
+	}
+	custom := &ConsoleLinkTable{}
+	if err := json.Unmarshal(data, custom); err != nil {
+		return nil, fmt.Errorf("parsing console links %s: %v", path, err)
+	}
+
+	table.merge(custom)
+	return table, nil
+}
+
+// merge copies the variables and links of other over the table
+func (t *ConsoleLinkTable) merge(other *ConsoleLinkTable) {
+	if t.Variables == nil {
+		t.Variables = make(map[string]string)
+	}
+	if t.Links == nil {
+		t.Links = make(map[string]string)
+	}
+	for name, value := range other.Variables {
+		t.Variables[name] = value
+	}
+	for resourceType, template := range other.Links {
+		if template == "" {
+			delete(t.Links, resourceType)
+			continue
+		}
+		t.Links[resourceType] = template
+	}
+}
+
+// templateFor returns the URL template for a resource type, preferring an exact
+// match over the longest matching prefix pattern
+func (t *ConsoleLinkTable) templateFor(resourceType string) string {
+	if template, ok := t.Links[resourceType]; ok {
+		return template
+	}
+
+	best, bestLength := "", -1
+	for pattern, template := range t.Links {
+		prefix, isPattern := strings.CutSuffix(pattern, "*")
+		if isPattern && strings.HasPrefix(resourceType, prefix) && len(prefix) > bestLength {
+			best, bestLength = template, len(prefix)
+		}
+	}
+	return best
+}
+
+// consoleURL builds the console link for a resource, or returns "" when the type has
+// no template or a placeholder cannot be resolved
+func (t *ConsoleLinkTable) consoleURL(resource Resource) string {
+	if t == nil || resource.Mode == "data" {
+		return ""
+	}
+	template := t.templateFor(resource.Type)
+	if template == "" {
+		return ""
+	}
+
+	var link strings.Builder
+	for {
+		start := strings.Index(template, "{")
+		if start < 0 {
+			link.WriteString(template)
+			break
+		}
+		end := strings.Index(template[start:], "}")
+		if end < 0 {
+			return ""
+		}
+		end += start
+
+		value, ok := t.resolvePlaceholder(resource, template[start+1:end])
+		if !ok {
+			return ""
+		}
+		link.WriteString(template[:start])
+		link.WriteString(value)
+		template = template[end+1:]
+	}
+
+	return link.String()
+}
+
+// resolvePlaceholder looks a placeholder up in the resource values, the derived
+// location and the table variables, in that order
+func (t *ConsoleLinkTable) resolvePlaceholder(resource Resource, placeholder string) (string, bool) {
+	path, raw := strings.CutSuffix(placeholder, ":raw")
+
+	value := ""
+	switch attribute := lookupResourceValue(resource, path).(type) {
+	case string:
+		value = attribute
+	case float64:
+		value = formatQueryValue(attribute)
+	}
+
+	if value == "" {
+		switch path {
+		case "region":
+			value, raw = resource.Region, false
+		case "account":
+			value, raw = resource.Account, false
+		}
+	}
+	if value == "" {
+		if variable, ok := t.Variables[path]; ok && variable != "" {
+			return variable, true
+		}
+		return "", false
+	}
+
+	if raw {
+		return value, true
+	}
+	return url.PathEscape(value), true
+}
+
+// consoleLinkHtml renders the "open in console" link shown in a resource card header
+func consoleLinkHtml(table *ConsoleLinkTable, resource Resource) string {
+	link := table.consoleURL(resource)
+	if link == "" {
+		return ""
+	}
+	return fmt.Sprintf(`<a class="console-link" href="%s" target="_blank" rel="noopener" onclick="event.stopPropagation()">open in console &#8599;</a>`,
+		escapeHtml(link))
+}
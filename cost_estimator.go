@@ -0,0 +1,411 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// builtinPricingCatalog is the pricing catalog shipped with the binary
+//
+//go:embed pricing_catalog.json
+var builtinPricingCatalog []byte
+
+// PricingCatalog maps resource types to prices used for offline cost estimates
+type PricingCatalog struct {
+	Name     string `json:"name"`
+	Currency string `json:"currency"`
+	// Region is the region the prices apply to; resources elsewhere are priced at its rates
+	Region        string                 `json:"region,omitempty"`
+	HoursPerMonth float64                `json:"hours_per_month"`
+	Resources     map[string]PricingRule `json:"resources"`
+	Free          []string               `json:"free"`
+	UsageBased    []string               `json:"usage_based"`
+}
+
+// PricingRule describes how the monthly cost of a resource type is calculated
+type PricingRule struct {
+	Attribute            string             `json:"attribute,omitempty"`
+	Hourly               map[string]float64 `json:"hourly,omitempty"`
+	HourlyFrom           string             `json:"hourly_from,omitempty"`
+	HourlyFlat           float64            `json:"hourly_flat,omitempty"`
+	MonthlyFlat          float64            `json:"monthly_flat,omitempty"`
+	CountAttribute       string             `json:"count_attribute,omitempty"`
+	StorageAttribute     string             `json:"storage_attribute,omitempty"`
+	StorageTypeAttribute string             `json:"storage_type_attribute,omitempty"`
+	StorageGBMonth       map[string]float64 `json:"storage_gb_month,omitempty"`
+	StorageGBMonthFrom   string             `json:"storage_gb_month_from,omitempty"`
+}
+
+// ResourceCost is the estimated monthly cost of a single resource
+type ResourceCost struct {
+	Address string  `json:"address"`
+	Module  string  `json:"module"`
+	Type    string  `json:"type"`
+	Monthly float64 `json:"monthly"`
+	Detail  string  `json:"detail,omitempty"`
+}
+
+// UnpricedResource is a resource the catalog could not price
+type UnpricedResource struct {
+	Address string `json:"address"`
+	Type    string `json:"type"`
+	Reason  string `json:"reason"`
+}
+
+// CostGroup sums the estimated cost of the resources in a module
+type CostGroup struct {
+	Name      string  `json:"name"`
+	Monthly   float64 `json:"monthly"`
+	Resources int     `json:"resources"`
+}
+
+// CostReport holds the cost estimate for a whole state
+type CostReport struct {
+	Catalog  string `json:"catalog"`
+	Currency string `json:"currency"`
+	Region   string `json:"region,omitempty"`
+	// OtherRegions counts priced resources located outside the catalog's region
+	OtherRegions int                `json:"other_regions,omitempty"`
+	Total        float64            `json:"total"`
+	Resources    []ResourceCost     `json:"resources"`
+	ByModule     []CostGroup        `json:"by_module"`
+	Unpriced     []UnpricedResource `json:"unpriced"`
+}
+
+// loadPricingCatalog loads a pricing catalog from a local file, or the built-in catalog when path is empty
+func loadPricingCatalog(path string) (*PricingCatalog, error) {
+	data := builtinPricingCatalog
+	if path != "" {
+		var err error
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pricing catalog %s: %v", path, err)
+		}
+	}
+
+	catalog := &PricingCatalog{}
+	if err := json.Unmarshal(data, catalog); err != nil {
+		return nil, fmt.Errorf("parsing pricing catalog: %v", err)
+	}
+	if catalog.HoursPerMonth == 0 {
+		catalog.HoursPerMonth = 730
+	}
+	if catalog.Currency == "" {
+		catalog.Currency = "USD"
+	}
+
+	return catalog, nil
+}
+
+// estimateCosts estimates the monthly cost of every managed resource in the state
+func estimateCosts(stateData *StateData, catalog *PricingCatalog) *CostReport {
+	report := &CostReport{Catalog: catalog.Name, Currency: catalog.Currency, Region: catalog.Region}
+	byModule := make(map[string]*CostGroup)
+
+	for _, resource := range stateData.Resources {
+		if resource.Mode == "data" || containsString(catalog.Free, resource.Type) {
+			continue
+		}
+
+		if containsString(catalog.UsageBased, resource.Type) {
+			report.Unpriced = append(report.Unpriced, UnpricedResource{Address: resource.Address, Type: resource.Type, Reason: "usage-based pricing"})
+			continue
+		}
+
+		rule, ok := catalog.Resources[resource.Type]
+		if !ok {
+			report.Unpriced = append(report.Unpriced, UnpricedResource{Address: resource.Address, Type: resource.Type, Reason: "resource type not in pricing catalog"})
+			continue
+		}
+
+		monthly, detail, err := estimateResourceCost(resource, rule, catalog)
+		if err != nil {
+			report.Unpriced = append(report.Unpriced, UnpricedResource{Address: resource.Address, Type: resource.Type, Reason: err.Error()})
+			continue
+		}
+
+		module := moduleDisplayName(resource.ModuleAddress)
+		report.Resources = append(report.Resources, ResourceCost{
+			Address: resource.Address,
+			Module:  module,
+			Type:    resource.Type,
+			Monthly: monthly,
+			Detail:  detail,
+		})
+		report.Total += monthly
+		if catalog.Region != "" && resource.Region != "" && resource.Region != catalog.Region {
+			report.OtherRegions++
+		}
+
+		group, exists := byModule[module]
+		if !exists {
+			group = &CostGroup{Name: module}
+			byModule[module] = group
+		}
+		group.Monthly += monthly
+		group.Resources++
+	}
+
+	for _, group := range byModule {
+		report.ByModule = append(report.ByModule, *group)
+	}
+	sort.Slice(report.ByModule, func(i, j int) bool { return report.ByModule[i].Monthly > report.ByModule[j].Monthly })
+	sort.SliceStable(report.Resources, func(i, j int) bool { return report.Resources[i].Monthly > report.Resources[j].Monthly })
+
+	return report
+}
+
+// estimateResourceCost applies a pricing rule to a resource and returns its monthly cost
+func estimateResourceCost(resource Resource, rule PricingRule, catalog *PricingCatalog) (float64, string, error) {
+	var details []string
+	hourly := rule.HourlyFlat
+
+	if rule.Attribute != "" {
+		size := firstString(lookupResourceValue(resource, rule.Attribute))
+		if size == "" {
+			return 0, "", fmt.Errorf("%s is not set", rule.Attribute)
+		}
+
+		prices := rule.Hourly
+		if rule.HourlyFrom != "" {
+			prices = catalog.Resources[rule.HourlyFrom].Hourly
+		}
+		price, ok := prices[size]
+		if !ok {
+			return 0, "", fmt.Errorf("no price for %s %s", rule.Attribute, size)
+		}
+		hourly += price
+		details = append(details, size)
+	}
+
+	count := 1.0
+	if rule.CountAttribute != "" {
+		if value, ok := lookupResourceValue(resource, rule.CountAttribute).(float64); ok {
+			count = value
+		}
+		details = append(details, fmt.Sprintf("× %g", count))
+	}
+
+	monthly := (hourly*catalog.HoursPerMonth + rule.MonthlyFlat) * count
+
+	if rule.StorageAttribute != "" {
+		if size, ok := lookupResourceValue(resource, rule.StorageAttribute).(float64); ok && size > 0 {
+			storageType := "default"
+			if rule.StorageTypeAttribute != "" {
+				if value := firstString(lookupResourceValue(resource, rule.StorageTypeAttribute)); value != "" {
+					storageType = value
+				}
+			}
+
+			prices := rule.StorageGBMonth
+			if rule.StorageGBMonthFrom != "" {
+				prices = catalog.Resources[rule.StorageGBMonthFrom].StorageGBMonth
+			}
+			price, ok := prices[storageType]
+			if !ok {
+				price, ok = prices["default"]
+			}
+			if !ok {
+				return 0, "", fmt.Errorf("no storage price for %s", storageType)
+			}
+
+			monthly += size * price
+			storage := fmt.Sprintf("%g GB", size)
+			if storageType != "default" {
+				storage += " " + storageType
+			}
+			details = append(details, storage)
+		}
+	}
+
+	return monthly, strings.Join(details, ", "), nil
+}
+
+// firstString returns a string value, or the first element of a list of strings
+func firstString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []interface{}:
+		if len(v) > 0 {
+			if s, ok := v[0].(string); ok {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// formatMoney formats an amount in the catalog currency
+func formatMoney(amount float64, currency string) string {
+	if currency == "USD" {
+		return fmt.Sprintf("$%.2f", amount)
+	}
+	return fmt.Sprintf("%.2f %s", amount, currency)
+}
+
+// runCostCommand runs the "cost" subcommand
+func runCostCommand(args []string) error {
+	flags := flag.NewFlagSet("cost", flag.ExitOnError)
+	inputFile := flags.String("i", "", "Input file path (required)")
+	flags.String("pricing", "", "Pricing catalog JSON file (default: built-in catalog)")
+	format := flags.String("format", "text", "Output format: text, json")
+	configPath := defineSubcommandFlags(flags)
+	flags.Parse(args)
+
+	if err := validateInput(*inputFile); err != nil {
+		return err
+	}
+
+	// -pricing overrides pricing from the config file; with neither the built-in catalog is used
+	options, err := loadSubcommandOptions(flags, *configPath)
+	if err != nil {
+		return err
+	}
+	catalog := options.PricingCatalog
+	if catalog == nil {
+		if catalog, err = loadPricingCatalog(""); err != nil {
+			return err
+		}
+	}
+
+	logOutput = os.Stderr
+	stateData, err := loadSubcommandState(*inputFile, options)
+	if err != nil {
+		return err
+	}
+
+	report := estimateCosts(stateData, catalog)
+
+	switch *format {
+	case "json":
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding cost report: %v", err)
+		}
+		fmt.Println(string(data))
+	case "text":
+		fmt.Print(generateCostText(report, detectTerminal(os.Stdout)))
+	default:
+		return fmt.Errorf("unsupported cost output format '%s'", *format)
+	}
+
+	return nil
+}
+
+// regionNote warns that resources outside the catalog's region are priced at its rates
+func (r *CostReport) regionNote() string {
+	if r.OtherRegions == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d priced resources are outside %s and estimated at %s prices", r.OtherRegions, r.Region, r.Region)
+}
+
+// generateCostText renders the cost report for the terminal
+func generateCostText(report *CostReport, settings terminalSettings) string {
+	var text strings.Builder
+
+	text.WriteString(colorize("Estimated Monthly Cost", ansiBold, settings.Color) + "\n")
+	text.WriteString(fmt.Sprintf("Catalog: %s\n", report.Catalog))
+	if note := report.regionNote(); note != "" {
+		text.WriteString(colorize(note, ansiYellow, settings.Color) + "\n")
+	}
+	text.WriteString(fmt.Sprintf("Total: %s per month\n\n", formatMoney(report.Total, report.Currency)))
+
+	if len(report.Resources) > 0 {
+		var moduleRows [][]string
+		for _, group := range report.ByModule {
+			moduleRows = append(moduleRows, []string{group.Name, fmt.Sprintf("%d", group.Resources), formatMoney(group.Monthly, report.Currency)})
+		}
+		text.WriteString(renderTable([]string{"MODULE", "RESOURCES", "MONTHLY"}, moduleRows, settings) + "\n")
+
+		var resourceRows [][]string
+		for _, cost := range report.Resources {
+			resourceRows = append(resourceRows, []string{cost.Address, cost.Detail, formatMoney(cost.Monthly, report.Currency)})
+		}
+		text.WriteString(renderTable([]string{"RESOURCE", "DETAIL", "MONTHLY"}, resourceRows, settings) + "\n")
+	}
+
+	if len(report.Unpriced) > 0 {
+		var unpricedRows [][]string
+		for _, unpriced := range report.Unpriced {
+			unpricedRows = append(unpricedRows, []string{unpriced.Address, unpriced.Reason})
+		}
+		text.WriteString(renderTable([]string{"UNPRICED RESOURCE", "REASON"}, unpricedRows, settings))
+	}
+
+	return text.String()
+}
+
+// generateCostHtml renders the cost estimate section of the state overview
+func generateCostHtml(report *CostReport) string {
+	var html strings.Builder
+
+	regionNote := ""
+	if note := report.regionNote(); note != "" {
+		regionNote = "; " + escapeHtml(note)
+	}
+
+	html.WriteString(fmt.Sprintf(`<div style="margin-top: 20px;">
+			<h3>Estimated Monthly Cost</h3>
+			<p class="section-description">Offline estimate from the %s pricing catalog%s</p>
+			<div class="summary">
+				<div class="summary-item">
+					<div class="summary-number">%s</div>
+					<div class="summary-label">Total per Month</div>
+				</div>
+				<div class="summary-item">
+					<div class="summary-number">%d</div>
+					<div class="summary-label">Priced Resources</div>
+				</div>
+				<div class="summary-item">
+					<div class="summary-number">%d</div>
+					<div class="summary-label">Unpriced Resources</div>
+				</div>
+			</div>`,
+		escapeHtml(report.Catalog), regionNote, formatMoney(report.Total, report.Currency), len(report.Resources), len(report.Unpriced)))
+
+	if len(report.ByModule) > 0 {
+		html.WriteString(`<h4>By Module</h4><div style="margin-top: 10px;">`)
+		for _, group := range report.ByModule {
+			html.WriteString(fmt.Sprintf(`
+				<div style="padding: 5px 0; border-bottom: 1px solid var(--border);">
+					<span style="font-weight: bold; color: var(--heading);">%s:</span>
+					<span style="color: var(--accent); margin-left: 10px;">%s</span>
+					<span style="color: var(--muted); margin-left: 10px;">(%d resources)</span>
+				</div>`, escapeHtml(group.Name), formatMoney(group.Monthly, report.Currency), group.Resources))
+		}
+		html.WriteString(`</div>`)
+
+		html.WriteString(`<h4>By Resource</h4><div style="margin-top: 10px;">`)
+		for _, cost := range report.Resources {
+			html.WriteString(fmt.Sprintf(`
+				<div class="attribute-item">
+					<span class="attribute-key">%s:</span>
+					<span class="attribute-value">%s</span>
+					<span class="attribute-value">%s</span>
+				</div>`, escapeHtml(cost.Address), formatMoney(cost.Monthly, report.Currency), escapeHtml(cost.Detail)))
+		}
+		html.WriteString(`</div>`)
+	}
+
+	if len(report.Unpriced) > 0 {
+		html.WriteString(`<h4>Unpriced</h4><div style="margin-top: 10px;">`)
+		for _, unpriced := range report.Unpriced {
+			html.WriteString(fmt.Sprintf(`
+				<div class="attribute-item">
+					<span class="attribute-key">%s:</span>
+					<span class="attribute-value">%s</span>
+				</div>`, escapeHtml(unpriced.Address), escapeHtml(unpriced.Reason)))
+		}
+		html.WriteString(`</div>`)
+	}
+
+	html.WriteString(`</div>`)
+	return html.String()
+}
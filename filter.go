@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ResourceFilter selects the resources that are rendered; an empty filter keeps everything
+type ResourceFilter struct {
+	// IncludeTypes and ExcludeTypes are resource type globs, e.g. aws_iam_*
+	IncludeTypes []string `yaml:"include_types,omitempty"`
+	ExcludeTypes []string `yaml:"exclude_types,omitempty"`
+	// Modules are module address globs; a module also selects its descendants and "root" selects the root module
+	Modules []string `yaml:"modules,omitempty"`
+	// IncludeAddresses and ExcludeAddresses are address globs, or regular expressions written as /regex/
+	IncludeAddresses []string `yaml:"include_addresses,omitempty"`
+	ExcludeAddresses []string `yaml:"exclude_addresses,omitempty"`
+	NoDataSources    bool     `yaml:"no_data_sources,omitempty"`
+}
+
+// FilterSummary records how a filter changed the state so renderers can say what was left out
+type FilterSummary struct {
+	Total    int
+	Excluded int
+	Criteria []string
+}
+
+// isEmpty reports whether the filter keeps every resource
+func (f ResourceFilter) isEmpty() bool {
+	return len(f.IncludeTypes) == 0 && len(f.ExcludeTypes) == 0 && len(f.Modules) == 0 &&
+		len(f.IncludeAddresses) == 0 && len(f.ExcludeAddresses) == 0 && !f.NoDataSources
+}
+
+// criteria describes the filter for section headers and log messages
+func (f ResourceFilter) criteria() []string {
+	var criteria []string
+	for _, entry := range []struct {
+		label  string
+		values []string
+	}{
+		{"type", f.IncludeTypes},
+		{"module", f.Modules},
+		{"address", f.IncludeAddresses},
+		{"excluding type", f.ExcludeTypes},
+		{"excluding address", f.ExcludeAddresses},
+	} {
+		if len(entry.values) > 0 {
+			criteria = append(criteria, entry.label+" "+strings.Join(entry.values, ", "))
+		}
+	}
+	if f.NoDataSources {
+		criteria = append(criteria, "no data sources")
+	}
+	return criteria
+}
+
+// compileGlob compiles a glob where "*" matches any run of characters and "?" a
+// single character; brackets in resource addresses are matched literally
+func compileGlob(pattern string) (*regexp.Regexp, error) {
+	expression := regexp.QuoteMeta(pattern)
+	expression = strings.ReplaceAll(expression, `\*`, ".*")
+	expression = strings.ReplaceAll(expression, `\?`, ".")
+	compiled, err := regexp.Compile("^" + expression + "$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern '%s': %v", pattern, err)
+	}
+	return compiled, nil
+}
+
+// compilePatterns compiles globs, treating values written as /regex/ as regular expressions
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			expression, err := regexp.Compile(pattern[1 : len(pattern)-1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression '%s': %v", pattern, err)
+			}
+			compiled = append(compiled, expression)
+			continue
+		}
+		glob, err := compileGlob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, glob)
+	}
+	return compiled, nil
+}
+
+// matchesAny reports whether any pattern matches the value
+func matchesAny(patterns []*regexp.Regexp, value string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// matcher compiles the filter into a predicate that reports whether a resource is kept
+func (f ResourceFilter) matcher() (func(Resource) bool, error) {
+	includeTypes, err := compilePatterns(f.IncludeTypes)
+	if err != nil {
+		return nil, err
+	}
+	excludeTypes, err := compilePatterns(f.ExcludeTypes)
+	if err != nil {
+		return nil, err
+	}
+	includeAddresses, err := compilePatterns(f.IncludeAddresses)
+	if err != nil {
+		return nil, err
+	}
+	excludeAddresses, err := compilePatterns(f.ExcludeAddresses)
+	if err != nil {
+		return nil, err
+	}
+
+	includeRoot := false
+	var modules []string
+	for _, module := range f.Modules {
+		if module == "root" || module == moduleNodeID("") {
+			includeRoot = true
+			continue
+		}
+		// Instances of a counted or for_each module, e.g. module.vpc[0], belong to module.vpc
+		modules = append(modules, module, module+".*", module+"[*")
+	}
+	modulePatterns, err := compilePatterns(modules)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(resource Resource) bool {
+		if f.NoDataSources && resource.Mode == "data" {
+			return false
+		}
+		if len(includeTypes) > 0 && !matchesAny(includeTypes, resource.Type) {
+			return false
+		}
+		if matchesAny(excludeTypes, resource.Type) {
+			return false
+		}
+		if len(f.Modules) > 0 {
+			inRoot := includeRoot && resource.ModuleAddress == ""
+			if !inRoot && (resource.ModuleAddress == "" || !matchesAny(modulePatterns, resource.ModuleAddress)) {
+				return false
+			}
+		}
+		if len(includeAddresses) > 0 && !matchesAny(includeAddresses, resource.Address) {
+			return false
+		}
+		return !matchesAny(excludeAddresses, resource.Address)
+	}, nil
+}
+
+// applyResourceFilter prunes the state before rendering and records a summary of
+// what was excluded on the state
+func applyResourceFilter(stateData *StateData, filter ResourceFilter) error {
+	if filter.isEmpty() {
+		return nil
+	}
+
+	keep, err := filter.matcher()
+	if err != nil {
+		return fmt.Errorf("resource filter: %v", err)
+	}
+
+	total := len(stateData.Resources)
+	excluded := pruneResources(stateData, keep)
+	stateData.Filter = &FilterSummary{Total: total, Excluded: excluded, Criteria: filter.criteria()}
+
+	fmt.Fprintf(logOutput, "Filtered out %d of %d resources (%s)\n", excluded, total, strings.Join(stateData.Filter.Criteria, "; "))
+	return nil
+}
+
+// pruneResources removes the resources for which keep returns false from the resource
+// list, the root module and every child module, drops modules left empty by the
+// pruning, and recounts ResourceCounts. It returns the number of resources removed.
+func pruneResources(stateData *StateData, keep func(Resource) bool) int {
+	filter := func(resources []Resource) []Resource {
+		var kept []Resource
+		for _, resource := range resources {
+			if keep(resource) {
+				kept = append(kept, resource)
+			}
+		}
+		return kept
+	}
+
+	before := len(stateData.Resources)
+	stateData.Resources = filter(stateData.Resources)
+	stateData.RootModule.Resources = filter(stateData.RootModule.Resources)
+
+	var pruneModules func(modules []Module) []Module
+	pruneModules = func(modules []Module) []Module {
+		var kept []Module
+		for _, module := range modules {
+			hadResources := countModuleResources(module) > 0
+			module.Resources = filter(module.Resources)
+			module.ChildModules = pruneModules(module.ChildModules)
+			if hadResources && countModuleResources(module) == 0 {
+				continue
+			}
+			kept = append(kept, module)
+		}
+		return kept
+	}
+	stateData.RootModule.ChildModules = pruneModules(stateData.RootModule.ChildModules)
+
+	stateData.ResourceCounts = make(map[string]int)
+	for _, resource := range stateData.Resources {
+		resourceTypeKey := resource.Type
+		if resource.Mode == "data" {
+			resourceTypeKey = "data." + resource.Type
+		}
+		stateData.ResourceCounts[resourceTypeKey]++
+	}
+
+	return before - len(stateData.Resources)
+}
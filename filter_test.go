@@ -0,0 +1,238 @@
+package main
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// newFilterTestState builds a state with root resources, counted and for_each module
+// instances, a nested module and a module whose name shares a prefix with another
+func newFilterTestState() *StateData {
+	resource := func(module, mode, resourceType, name string) Resource {
+		address := resourceType + "." + name
+		if mode == "data" {
+			address = "data." + address
+		}
+		if module != "" {
+			address = module + "." + address
+		}
+		return Resource{Address: address, Mode: mode, Type: resourceType, Name: name, ModuleAddress: module}
+	}
+
+	web := resource("", "managed", "aws_instance", "web")
+	region := resource("", "data", "aws_region", "current")
+	subnet0 := resource("module.vpc[0]", "managed", "aws_subnet", "a")
+	nat := resource("module.vpc[0].module.nat", "managed", "aws_nat_gateway", "main")
+	subnetB := resource(`module.vpc["b"]`, "managed", "aws_subnet", "a")
+	vpcx := resource("module.vpcx", "managed", "aws_vpc", "main")
+	database := resource("module.db", "managed", "aws_db_instance", "main")
+	role := resource("module.db", "managed", "aws_iam_role", "db")
+
+	stateData := &StateData{
+		Resources: []Resource{web, region, subnet0, nat, subnetB, vpcx, database, role},
+		RootModule: RootModule{
+			Resources: []Resource{web, region},
+			ChildModules: []Module{
+				{Address: "module.vpc[0]", Resources: []Resource{subnet0}, ChildModules: []Module{
+					{Address: "module.vpc[0].module.nat", Resources: []Resource{nat}},
+				}},
+				{Address: `module.vpc["b"]`, Resources: []Resource{subnetB}},
+				{Address: "module.vpcx", Resources: []Resource{vpcx}},
+				{Address: "module.db", Resources: []Resource{database, role}},
+			},
+		},
+	}
+	return stateData
+}
+
+// keptAddresses returns the addresses of the resources the filter keeps, sorted
+func keptAddresses(t *testing.T, filter ResourceFilter) []string {
+	t.Helper()
+	keep, err := filter.matcher()
+	if err != nil {
+		t.Fatalf("matcher: %v", err)
+	}
+	var addresses []string
+	for _, resource := range newFilterTestState().Resources {
+		if keep(resource) {
+			addresses = append(addresses, resource.Address)
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+func TestResourceFilterMatcher(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter ResourceFilter
+		want   []string
+	}{
+		{
+			name:   "module selects its instances and descendants",
+			filter: ResourceFilter{Modules: []string{"module.vpc"}},
+			want:   []string{`module.vpc["b"].aws_subnet.a`, "module.vpc[0].aws_subnet.a", "module.vpc[0].module.nat.aws_nat_gateway.main"},
+		},
+		{
+			name:   "module instance",
+			filter: ResourceFilter{Modules: []string{"module.vpc[0]"}},
+			want:   []string{"module.vpc[0].aws_subnet.a", "module.vpc[0].module.nat.aws_nat_gateway.main"},
+		},
+		{
+			name:   "nested module",
+			filter: ResourceFilter{Modules: []string{"module.vpc[*].module.nat"}},
+			want:   []string{"module.vpc[0].module.nat.aws_nat_gateway.main"},
+		},
+		{
+			name:   "root and module glob",
+			filter: ResourceFilter{Modules: []string{"root", "module.d*"}},
+			want:   []string{"aws_instance.web", "data.aws_region.current", "module.db.aws_db_instance.main", "module.db.aws_iam_role.db"},
+		},
+		{
+			name:   "include and exclude types",
+			filter: ResourceFilter{IncludeTypes: []string{"aws_*"}, ExcludeTypes: []string{"aws_subnet", "aws_iam_*"}},
+			want:   []string{"aws_instance.web", "data.aws_region.current", "module.db.aws_db_instance.main", "module.vpc[0].module.nat.aws_nat_gateway.main", "module.vpcx.aws_vpc.main"},
+		},
+		{
+			name:   "address glob with literal brackets",
+			filter: ResourceFilter{IncludeAddresses: []string{"module.vpc[0].*"}},
+			want:   []string{"module.vpc[0].aws_subnet.a", "module.vpc[0].module.nat.aws_nat_gateway.main"},
+		},
+		{
+			name:   "address regular expression",
+			filter: ResourceFilter{ExcludeAddresses: []string{`/^module\.(vpc|db)\b/`}},
+			want:   []string{"aws_instance.web", "data.aws_region.current", "module.vpcx.aws_vpc.main"},
+		},
+		{
+			name:   "no data sources",
+			filter: ResourceFilter{Modules: []string{"root"}, NoDataSources: true},
+			want:   []string{"aws_instance.web"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := keptAddresses(t, test.filter)
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("kept %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestResourceFilterInvalidPattern(t *testing.T) {
+	_, err := ResourceFilter{IncludeAddresses: []string{"/[/"}}.matcher()
+	if err == nil || !strings.Contains(err.Error(), "invalid regular expression '/[/'") {
+		t.Errorf("error = %v, want an invalid regular expression error", err)
+	}
+}
+
+// moduleAddresses lists the module tree as indented addresses with resource counts
+func moduleAddresses(modules []Module, indent string) []string {
+	var lines []string
+	for _, module := range modules {
+		lines = append(lines, indent+module.Address+" "+strings.Repeat("r", len(module.Resources)))
+		lines = append(lines, moduleAddresses(module.ChildModules, indent+"  ")...)
+	}
+	return lines
+}
+
+func TestPruneResources(t *testing.T) {
+	tests := []struct {
+		name     string
+		keep     func(Resource) bool
+		excluded int
+		root     int
+		modules  []string
+		counts   map[string]int
+	}{
+		{
+			name:     "keep everything",
+			keep:     func(Resource) bool { return true },
+			excluded: 0,
+			root:     2,
+			modules: []string{
+				"module.vpc[0] r", "  module.vpc[0].module.nat r", `module.vpc["b"] r`, "module.vpcx r", "module.db rr",
+			},
+			counts: map[string]int{"aws_instance": 1, "data.aws_region": 1, "aws_subnet": 2, "aws_nat_gateway": 1, "aws_vpc": 1, "aws_db_instance": 1, "aws_iam_role": 1},
+		},
+		{
+			name:     "parent kept for a nested module",
+			keep:     func(resource Resource) bool { return resource.Type == "aws_nat_gateway" },
+			excluded: 7,
+			root:     0,
+			modules:  []string{"module.vpc[0] ", "  module.vpc[0].module.nat r"},
+			counts:   map[string]int{"aws_nat_gateway": 1},
+		},
+		{
+			name:     "partially pruned module",
+			keep:     func(resource Resource) bool { return resource.Type != "aws_iam_role" && resource.Mode != "data" },
+			excluded: 2,
+			root:     1,
+			modules: []string{
+				"module.vpc[0] r", "  module.vpc[0].module.nat r", `module.vpc["b"] r`, "module.vpcx r", "module.db r",
+			},
+			counts: map[string]int{"aws_instance": 1, "aws_subnet": 2, "aws_nat_gateway": 1, "aws_vpc": 1, "aws_db_instance": 1},
+		},
+		{
+			name:     "keep nothing",
+			keep:     func(Resource) bool { return false },
+			excluded: 8,
+			root:     0,
+			counts:   map[string]int{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stateData := newFilterTestState()
+			excluded := pruneResources(stateData, test.keep)
+
+			if excluded != test.excluded {
+				t.Errorf("excluded %d, want %d", excluded, test.excluded)
+			}
+			if len(stateData.Resources) != 8-test.excluded {
+				t.Errorf("%d resources left, want %d", len(stateData.Resources), 8-test.excluded)
+			}
+			if len(stateData.RootModule.Resources) != test.root {
+				t.Errorf("%d root module resources, want %d", len(stateData.RootModule.Resources), test.root)
+			}
+			if got := moduleAddresses(stateData.RootModule.ChildModules, ""); strings.Join(got, "\n") != strings.Join(test.modules, "\n") {
+				t.Errorf("modules:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(test.modules, "\n"))
+			}
+			if len(stateData.ResourceCounts) != len(test.counts) {
+				t.Errorf("resource counts %v, want %v", stateData.ResourceCounts, test.counts)
+			}
+			for key, count := range test.counts {
+				if stateData.ResourceCounts[key] != count {
+					t.Errorf("resource count %s = %d, want %d", key, stateData.ResourceCounts[key], count)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyResourceFilterSummary(t *testing.T) {
+	logOutput = io.Discard
+	stateData := newFilterTestState()
+
+	filter := ResourceFilter{Modules: []string{"module.vpc"}, NoDataSources: true}
+	if err := applyResourceFilter(stateData, filter); err != nil {
+		t.Fatalf("applyResourceFilter: %v", err)
+	}
+
+	summary := stateData.Filter
+	if summary == nil || summary.Total != 8 || summary.Excluded != 5 {
+		t.Fatalf("summary = %+v, want 5 of 8 excluded", summary)
+	}
+	if got := strings.Join(summary.Criteria, "; "); got != "module module.vpc; no data sources" {
+		t.Errorf("criteria = %q", got)
+	}
+
+	unfiltered := newFilterTestState()
+	if err := applyResourceFilter(unfiltered, ResourceFilter{}); err != nil || unfiltered.Filter != nil {
+		t.Errorf("empty filter changed the state: %v, %+v", err, unfiltered.Filter)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CommitInfo describes the git commit a state snapshot was read from
+type CommitInfo struct {
+	Hash    string
+	Author  string
+	Time    time.Time
+	Subject string
+}
+
+// ShortHash returns the abbreviated commit hash
+func (c CommitInfo) ShortHash() string {
+	if len(c.Hash) > 7 {
+		return c.Hash[:7]
+	}
+	return c.Hash
+}
+
+// runGit runs a git command in a repository and returns its output
+func runGit(repo string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], message)
+		}
+		return nil, fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return output, nil
+}
+
+// gitCommits lists the commits that changed a file, oldest first
+func gitCommits(repo, path string) ([]CommitInfo, error) {
+	// Fields are separated by the unit separator, commits by NUL
+	output, err := runGit(repo, "log", "--reverse", "-z", "--format=%H%x1f%an%x1f%at%x1f%s", "--", path)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []CommitInfo
+	for _, record := range strings.Split(string(output), "\x00") {
+		fields := strings.Split(strings.TrimSpace(record), "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		seconds, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid commit time '%s': %v", fields[2], err)
+		}
+		commits = append(commits, CommitInfo{
+			Hash:    fields[0],
+			Author:  fields[1],
+			Time:    time.Unix(seconds, 0),
+			Subject: fields[3],
+		})
+	}
+	return commits, nil
+}
+
+// loadGitSnapshots reads every committed revision of a state file from a local git
+// repository, oldest first. Revisions that delete the file or cannot be parsed are skipped.
+func loadGitSnapshots(repo, path string) ([]Snapshot, error) {
+	// Paths are relative to the repository directory, as with git itself
+	if filepath.IsAbs(path) {
+		relative, err := filepath.Rel(repo, path)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not inside %s", path, repo)
+		}
+		path = relative
+	}
+	path = "./" + filepath.ToSlash(path)
+
+	commits, err := gitCommits(repo, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no commits in %s change %s", repo, path)
+	}
+
+	var snapshots []Snapshot
+	for _, commit := range commits {
+		content, err := runGit(repo, "show", commit.Hash+":"+path)
+		if err != nil {
+			fmt.Fprintf(logOutput, "Skipping %s: %s is not in this commit\n", commit.ShortHash(), path)
+			continue
+		}
+		stateData, err := parseStateJSON(content)
+		if err != nil {
+			fmt.Fprintf(logOutput, "Skipping %s: %v\n", commit.ShortHash(), err)
+			continue
+		}
+
+		snapshots = append(snapshots, Snapshot{
+			Source:    strings.TrimPrefix(path, "./") + "@" + commit.ShortHash(),
+			Timestamp: commit.Time,
+			State:     stateData,
+			Commit:    &commit,
+		})
+	}
+
+	fmt.Fprintf(logOutput, "Loaded %d revisions of %s from %s\n", len(snapshots), path, repo)
+	return snapshots, nil
+}
@@ -1,3 +1,11 @@
 module terraform-state-visualizer
 
 go 1.25.3
+
+require (
+	github.com/klauspost/compress v1.20.1
+	golang.org/x/term v0.38.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/sys v0.39.0 // indirect
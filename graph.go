@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Graph levels control how resources are collapsed when building a graph
+const (
+	graphLevelResource = "resource"
+	graphLevelType     = "type"
+	graphLevelModule   = "module"
+)
+
+// Graph edge kinds
+const (
+	edgeKindDependsOn = "depends_on"
+	edgeKindContains  = "contains"
+)
+
+// GraphNode represents a single node in the resource graph
+type GraphNode struct {
+	ID           string
+	Label        string
+	Module       string
+	Mode         string
+	Type         string
+	ProviderName string
+	Count        int
+}
+
+// GraphEdge represents a directed edge between two graph nodes
+type GraphEdge struct {
+	From string
+	To   string
+	Kind string
+}
+
+// GraphCluster groups the nodes that belong to a module
+type GraphCluster struct {
+	Address  string
+	Nodes    []string
+	Children []*GraphCluster
+}
+
+// ResourceGraph represents the dependency graph of a Terraform state
+type ResourceGraph struct {
+	Level string
+	Nodes []GraphNode
+	Edges []GraphEdge
+	Root  *GraphCluster
+}
+
+// validGraphLevel checks whether the given graph level is supported
+func validGraphLevel(level string) bool {
+	switch level {
+	case graphLevelResource, graphLevelType, graphLevelModule:
+		return true
+	default:
+		return false
+	}
+}
+
+// buildResourceGraph builds a dependency graph from the parsed state data
+func buildResourceGraph(stateData *StateData, level string) (*ResourceGraph, error) {
+	if !validGraphLevel(level) {
+		return nil, fmt.Errorf("unsupported graph level '%s' (expected resource, type or module)", level)
+	}
+
+	graph := &ResourceGraph{Level: level}
+	nodeIndex := make(map[string]int)
+
+	// Map every resource to the node it is collapsed into
+	resourceNodes := make(map[string]string)
+	for _, resource := range stateData.Resources {
+		nodeID := graphNodeID(resource, level)
+		resourceNodes[resource.Address] = nodeID
+
+		if i, exists := nodeIndex[nodeID]; exists {
+			graph.Nodes[i].Count++
+			continue
+		}
+
+		node := GraphNode{
+			ID:           nodeID,
+			Module:       resource.ModuleAddress,
+			Mode:         resource.Mode,
+			Type:         resource.Type,
+			ProviderName: resource.ProviderName,
+			Count:        1,
+		}
+		switch level {
+		case graphLevelResource:
+			node.Label = strings.TrimPrefix(resource.Address, resource.ModuleAddress+".")
+		case graphLevelType:
+			node.Label = resource.Type
+			if resource.Mode == "data" {
+				node.Label = "data." + resource.Type
+			}
+		case graphLevelModule:
+			node.Label = moduleDisplayName(resource.ModuleAddress)
+			node.Mode = "module"
+			node.Type = ""
+		}
+
+		nodeIndex[nodeID] = len(graph.Nodes)
+		graph.Nodes = append(graph.Nodes, node)
+	}
+
+	// Module level graphs also show modules that only contain other modules
+	if level == graphLevelModule {
+		walkModules(stateData.RootModule.ChildModules, func(module Module, parent string) {
+			if _, exists := nodeIndex[module.Address]; !exists {
+				nodeIndex[module.Address] = len(graph.Nodes)
+				graph.Nodes = append(graph.Nodes, GraphNode{
+					ID:     module.Address,
+					Label:  moduleDisplayName(module.Address),
+					Module: module.Address,
+					Mode:   "module",
+				})
+			}
+			graph.Edges = append(graph.Edges, GraphEdge{From: moduleNodeID(parent), To: module.Address, Kind: edgeKindContains})
+		})
+		if _, exists := nodeIndex[moduleNodeID("")]; !exists && len(stateData.RootModule.ChildModules) > 0 {
+			nodeIndex[moduleNodeID("")] = len(graph.Nodes)
+			graph.Nodes = append(graph.Nodes, GraphNode{ID: moduleNodeID(""), Label: moduleDisplayName(""), Mode: "module"})
+		}
+	}
+
+	// Add dependency edges between the collapsed nodes
+	seen := make(map[GraphEdge]bool)
+	for _, edge := range graph.Edges {
+		seen[edge] = true
+	}
+	for _, resource := range stateData.Resources {
+		from := resourceNodes[resource.Address]
+		for _, dep := range resource.DependsOn {
+			for _, target := range resolveDependency(stateData, dep) {
+				to := resourceNodes[target]
+				edge := GraphEdge{From: from, To: to, Kind: edgeKindDependsOn}
+				if from == to || seen[edge] {
+					continue
+				}
+				seen[edge] = true
+				graph.Edges = append(graph.Edges, edge)
+			}
+		}
+	}
+
+	sort.SliceStable(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].From != graph.Edges[j].From {
+			return graph.Edges[i].From < graph.Edges[j].From
+		}
+		return graph.Edges[i].To < graph.Edges[j].To
+	})
+
+	// Module level graphs are flat, every other level is clustered by module
+	graph.Root = &GraphCluster{}
+	if level != graphLevelModule {
+		graph.Root = buildGraphCluster("", stateData.RootModule.ChildModules, graph.Nodes)
+	} else {
+		for _, node := range graph.Nodes {
+			graph.Root.Nodes = append(graph.Root.Nodes, node.ID)
+		}
+	}
+
+	return graph, nil
+}
+
+// buildGraphCluster builds the cluster tree for a module and its children
+func buildGraphCluster(address string, childModules []Module, nodes []GraphNode) *GraphCluster {
+	cluster := &GraphCluster{Address: address}
+
+	for _, node := range nodes {
+		if node.Module == address {
+			cluster.Nodes = append(cluster.Nodes, node.ID)
+		}
+	}
+
+	for _, childModule := range childModules {
+		child := buildGraphCluster(childModule.Address, childModule.ChildModules, nodes)
+		if len(child.Nodes) > 0 || len(child.Children) > 0 {
+			cluster.Children = append(cluster.Children, child)
+		}
+	}
+
+	return cluster
+}
+
+// graphNodeID returns the ID of the node a resource is collapsed into
+func graphNodeID(resource Resource, level string) string {
+	switch level {
+	case graphLevelType:
+		typeKey := resource.Type
+		if resource.Mode == "data" {
+			typeKey = "data." + resource.Type
+		}
+		if resource.ModuleAddress != "" {
+			return resource.ModuleAddress + "." + typeKey
+		}
+		return typeKey
+	case graphLevelModule:
+		return moduleNodeID(resource.ModuleAddress)
+	default:
+		return resource.Address
+	}
+}
+
+// moduleNodeID returns the node ID used for a module, including the root module
+func moduleNodeID(address string) string {
+	if address == "" {
+		return "root"
+	}
+	return address
+}
+
+// moduleDisplayName returns a readable name for a module address
+func moduleDisplayName(address string) string {
+	if address == "" {
+		return "root module"
+	}
+	return address
+}
+
+// walkModules calls fn for every module in the tree along with its parent address
+func walkModules(modules []Module, fn func(module Module, parent string)) {
+	var walk func(modules []Module, parent string)
+	walk = func(modules []Module, parent string) {
+		for _, module := range modules {
+			fn(module, parent)
+			walk(module.ChildModules, module.Address)
+		}
+	}
+	walk(modules, "")
+}
+
+// resolveDependency returns the addresses of the resources a depends_on entry refers to
+func resolveDependency(stateData *StateData, dep string) []string {
+	var targets []string
+
+	for _, resource := range stateData.Resources {
+		switch {
+		case resource.Address == dep:
+			// Exact match on the resource address
+			targets = append(targets, resource.Address)
+		case strings.HasPrefix(resource.Address, dep+"["):
+			// Dependency on every instance of a counted resource
+			targets = append(targets, resource.Address)
+		case strings.HasPrefix(dep, "module.") && (resource.ModuleAddress == dep || strings.HasPrefix(resource.ModuleAddress, dep+".") || strings.HasPrefix(resource.ModuleAddress, dep+"[")):
+			// Dependency on a whole module
+			targets = append(targets, resource.Address)
+		}
+	}
+
+	return targets
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// generateDot renders the resource graph in Graphviz DOT format
+func generateDot(graph *ResourceGraph) string {
+	var dot strings.Builder
+
+	dot.WriteString("digraph terraform {\n")
+	dot.WriteString("  rankdir=LR;\n")
+	dot.WriteString("  compound=true;\n")
+	dot.WriteString(`  node [shape=box, style="rounded,filled", fillcolor="#ffffff", fontname="Helvetica", fontsize=11];` + "\n")
+	dot.WriteString(`  edge [color="#7f8c8d"];` + "\n")
+
+	nodes := make(map[string]GraphNode)
+	for _, node := range graph.Nodes {
+		nodes[node.ID] = node
+	}
+
+	writeDotCluster(&dot, graph.Root, nodes, 1)
+
+	for _, edge := range graph.Edges {
+		attributes := ""
+		if edge.Kind == edgeKindContains {
+			attributes = ` [style=dashed, arrowhead=none]`
+		}
+		dot.WriteString(fmt.Sprintf("  %s -> %s%s;\n", dotQuote(edge.From), dotQuote(edge.To), attributes))
+	}
+
+	dot.WriteString("}\n")
+	return dot.String()
+}
+
+// writeDotCluster writes the nodes of a cluster and its nested module subgraphs
+func writeDotCluster(dot *strings.Builder, cluster *GraphCluster, nodes map[string]GraphNode, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	for _, nodeID := range cluster.Nodes {
+		node := nodes[nodeID]
+		dot.WriteString(fmt.Sprintf("%s%s [label=%s, color=%s%s];\n",
+			indent, dotQuote(node.ID), dotQuote(graphNodeLabel(node)), dotQuote(graphNodeColor(node)), dotNodeShape(node)))
+	}
+
+	for _, child := range cluster.Children {
+		dot.WriteString(fmt.Sprintf("%ssubgraph %s {\n", indent, dotQuote("cluster_"+child.Address)))
+		dot.WriteString(fmt.Sprintf("%s  label=%s;\n", indent, dotQuote(child.Address)))
+		dot.WriteString(fmt.Sprintf("%s  style=rounded;\n", indent))
+		dot.WriteString(fmt.Sprintf("%s  color=\"#9b59b6\";\n", indent))
+		dot.WriteString(fmt.Sprintf("%s  fontcolor=\"#8e44ad\";\n", indent))
+		writeDotCluster(dot, child, nodes, depth+1)
+		dot.WriteString(indent + "}\n")
+	}
+}
+
+// dotNodeShape returns extra DOT attributes for the node shape
+func dotNodeShape(node GraphNode) string {
+	switch node.Mode {
+	case "data":
+		return ", shape=ellipse"
+	case "module":
+		return ", shape=folder"
+	default:
+		return ""
+	}
+}
+
+// dotQuote quotes a string as a DOT identifier
+func dotQuote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	return `"` + value + `"`
+}
+
+// generateMermaid renders the resource graph as a Mermaid flowchart
+func generateMermaid(graph *ResourceGraph) string {
+	var mermaid strings.Builder
+
+	mermaid.WriteString("flowchart LR\n")
+
+	// Mermaid identifiers cannot contain dots or brackets, so use generated IDs
+	ids := make(map[string]string)
+	nodes := make(map[string]GraphNode)
+	for i, node := range graph.Nodes {
+		ids[node.ID] = fmt.Sprintf("n%d", i)
+		nodes[node.ID] = node
+	}
+
+	clusterCount := 0
+	writeMermaidCluster(&mermaid, graph.Root, nodes, ids, 1, &clusterCount)
+
+	for _, edge := range graph.Edges {
+		arrow := "-->"
+		if edge.Kind == edgeKindContains {
+			arrow = "-.-"
+		}
+		mermaid.WriteString(fmt.Sprintf("  %s %s %s\n", ids[edge.From], arrow, ids[edge.To]))
+	}
+
+	mermaid.WriteString("  classDef managed fill:#ffffff,stroke:#27ae60\n")
+	mermaid.WriteString("  classDef data fill:#ffffff,stroke:#f39c12\n")
+	mermaid.WriteString("  classDef module fill:#ffffff,stroke:#9b59b6\n")
+
+	return mermaid.String()
+}
+
+// writeMermaidCluster writes the nodes of a cluster and its nested module subgraphs
+func writeMermaidCluster(mermaid *strings.Builder, cluster *GraphCluster, nodes map[string]GraphNode, ids map[string]string, depth int, clusterCount *int) {
+	indent := strings.Repeat("  ", depth)
+
+	for _, nodeID := range cluster.Nodes {
+		node := nodes[nodeID]
+		open, close := "[", "]"
+		switch node.Mode {
+		case "data":
+			open, close = "([", "])"
+		case "module":
+			open, close = "[[", "]]"
+		}
+
+		class := node.Mode
+		if class != "data" && class != "module" {
+			class = "managed"
+		}
+
+		mermaid.WriteString(fmt.Sprintf("%s%s%s%s%s:::%s\n", indent, ids[nodeID], open, mermaidQuote(graphNodeLabel(node)), close, class))
+	}
+
+	for _, child := range cluster.Children {
+		*clusterCount++
+		mermaid.WriteString(fmt.Sprintf("%ssubgraph m%d[%s]\n", indent, *clusterCount, mermaidQuote(child.Address)))
+		writeMermaidCluster(mermaid, child, nodes, ids, depth+1, clusterCount)
+		mermaid.WriteString(indent + "end\n")
+	}
+}
+
+// mermaidQuote quotes a string as a Mermaid label
+func mermaidQuote(value string) string {
+	value = strings.ReplaceAll(value, `"`, "#quot;")
+	return `"` + value + `"`
+}
+
+// graphNodeLabel returns the display label of a node, including collapsed counts
+func graphNodeLabel(node GraphNode) string {
+	if node.Count > 1 {
+		return fmt.Sprintf("%s (%d)", node.Label, node.Count)
+	}
+	return node.Label
+}
+
+// graphNodeColor returns the border color used for a node, matching the HTML page
+func graphNodeColor(node GraphNode) string {
+	switch node.Mode {
+	case "data":
+		return "#f39c12"
+	case "module":
+		return "#9b59b6"
+	default:
+		return "#27ae60"
+	}
+}
@@ -2,297 +2,19 @@ package main
 
 import (
 	"fmt"
+	"html"
+	"sort"
 	"strings"
 )
 
 // generateHtml creates the complete HTML visualization for Terraform state
-func generateHtml(stateData *StateData) string {
-	// Generate HTML content
-	html := `<!DOCTYPE html>
-<html lang="en">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Terraform State</title>
-    <style>
-        body {
-            font-family: Arial, sans-serif;
-            margin: 20px;
-            background-color: #f5f5f5;
-        }
-        .container {
-            max-width: 1200px;
-            margin: 0 auto;
-            background: white;
-            padding: 20px;
-            border-radius: 8px;
-            box-shadow: 0 2px 4px rgba(0,0,0,0.1);
-        }
-        h1 {
-            color: #2c3e50;
-            border-bottom: 2px solid #3498db;
-            padding-bottom: 10px;
-            display: flex;
-            justify-content: space-between;
-            align-items: center;
-        }
-        .source-link {
-            font-size: 14px;
-            font-weight: normal;
-            color: #3498db;
-            text-decoration: none;
-        }
-        .source-link:hover {
-            text-decoration: underline;
-        }
-        .promo-message {
-            text-align: center;
-            margin: 20px 0;
-            font-style: italic;
-        }
-        .promo-link {
-            color: #3498db;
-            text-decoration: none;
-            font-weight: bold;
-            font-style: normal;
-        }
-        .promo-link:hover {
-            text-decoration: underline;
-        }
-        .section-header-row {
-            display: flex;
-            justify-content: space-between;
-            align-items: center;
-            width: 100%;
-        }
-        .section-description {
-            font-size: 14px;
-            font-style: italic;
-            color: #6c757d;
-            margin-bottom: 15px;
-        }
-        .section {
-            margin: 20px 0;
-            padding: 15px;
-            background-color: #ecf0f1;
-            border-radius: 5px;
-        }
-        .resource-item {
-            margin: 10px 0;
-            padding: 10px;
-            background-color: white;
-            border-radius: 3px;
-            border-left: 4px solid #3498db;
-        }
-        .managed { border-left-color: #27ae60; }
-        .data { border-left-color: #f39c12; }
-        .resource-address {
-            font-family: monospace;
-            font-weight: bold;
-            color: #2c3e50;
-        }
-        .resource-type {
-            color: #7f8c8d;
-            font-size: 14px;
-        }
-        .resource-attributes {
-            margin-top: 10px;
-            padding: 10px;
-            background-color: #f8f9fa;
-            border-radius: 3px;
-            font-family: monospace;
-            font-size: 12px;
-        }
-        .collapsible {
-            cursor: pointer;
-            user-select: none;
-            display: flex;
-            align-items: center;
-            gap: 8px;
-        }
-        .collapsible:hover {
-            background-color: #f0f0f0;
-        }
-        .collapsible::before {
-            content: "▼";
-            font-size: 12px;
-            transition: transform 0.2s;
-            flex-shrink: 0;
-        }
-        .collapsible.collapsed::before {
-            content: "▶";
-        }
-        .collapsible-content {
-            overflow: hidden;
-            transition: opacity 0.3s ease-out, max-height 0.3s ease-out;
-        }
-        .collapsible-content.collapsed {
-            max-height: 0;
-            opacity: 0;
-        }
-        .collapsible-content:not(.collapsed) {
-            max-height: none;
-            opacity: 1;
-        }
-        .attribute-item {
-            margin: 5px 0;
-            padding: 3px 0;
-            border-bottom: 1px solid #e9ecef;
-        }
-        .attribute-key {
-            font-weight: bold;
-            color: #495057;
-        }
-        .attribute-value {
-            color: #6c757d;
-            margin-left: 10px;
-        }
-        .attribute-sensitive {
-            background-color: #fff3cd;
-            border-left: 3px solid #ffc107;
-            padding-left: 8px;
-        }
-        .summary {
-            display: flex;
-            gap: 20px;
-            margin-bottom: 20px;
-        }
-        .summary-item {
-            flex: 1;
-            text-align: center;
-            padding: 15px;
-            background-color: white;
-            border-radius: 5px;
-        }
-        .summary-number {
-            font-size: 24px;
-            font-weight: bold;
-            color: #2c3e50;
-        }
-        .summary-label {
-            color: #7f8c8d;
-            font-size: 14px;
-        }
-        .module-item {
-            margin: 10px 0;
-            padding: 15px;
-            background-color: white;
-            border-radius: 5px;
-            border-left: 4px solid #9b59b6;
-        }
-        .module-address {
-            font-family: monospace;
-            font-weight: bold;
-            color: #8e44ad;
-            font-size: 16px;
-        }
-        .module-resource-count {
-            color: #7f8c8d;
-            font-size: 14px;
-            margin-top: 5px;
-        }
-    </style>
-    <script>
-        function toggleCollapsible(element) {
-            const content = element.nextElementSibling;
-            element.classList.toggle('collapsed');
-            content.classList.toggle('collapsed');
-        }
-        
-        // Make individual resource items collapsed by default, but keep main sections open
-        document.addEventListener('DOMContentLoaded', function() {
-            const collapsibles = document.querySelectorAll('.collapsible');
-            collapsibles.forEach(function(element) {
-                // Check if this is a main section (State Overview, Resources, etc.)
-                const isMainSection = element.querySelector('h2') !== null;
-                
-                if (!isMainSection) {
-                    // Only collapse individual resource items, not main sections
-                    element.classList.add('collapsed');
-                    const content = element.nextElementSibling;
-                    if (content) {
-                        content.classList.add('collapsed');
-                    }
-                } else {
-                    // Check if main section has no items
-                    const section = element.closest('.section');
-                    const resourceItems = section.querySelectorAll('.resource-item, .module-item');
-                    if (resourceItems.length === 0) {
-                        element.classList.add('collapsed');
-                        const content = element.nextElementSibling;
-                        if (content) {
-                            content.classList.add('collapsed');
-                        }
-                    }
-                }
-            });
-        });
-    </script>
-</head>
-<body>
-    <div class="container">
-        <h1>Terraform State</h1>
-                
-        <div class="section">
-            <div class="collapsible" onclick="toggleCollapsible(this)">
-                <div class="section-header-row">
-                    <h2>State Overview</h2>
-                    <p class="section-description">Summary of your Terraform state</p>
-                </div>
-            </div>
-            <div class="collapsible-content">
-                ` + generateStateOverviewHtml(stateData) + `
-            </div>
-        </div>
-        
-        <div class="section">
-            <div class="collapsible" onclick="toggleCollapsible(this)">
-            <div class="section-header-row">
-                    <h2>Resources (` + fmt.Sprintf("%d", len(stateData.Resources)) + ` total)</h2>
-                    <p class="section-description">All resources in your Terraform state</p>
-                </div>
-            </div>
-            <div class="collapsible-content">
-                ` + generateResourcesHtml(stateData) + `
-            </div>
-        </div>
-        
-        <div class="section">
-            <div class="collapsible" onclick="toggleCollapsible(this)">
-            <div class="section-header-row">
-                    <h2>Outputs (` + fmt.Sprintf("%d", len(stateData.Outputs)) + ` total)</h2>
-                    <p class="section-description">Output values from your Terraform state</p>
-                </div>
-            </div>
-            <div class="collapsible-content">
-                ` + generateOutputsHtml(stateData) + `
-            </div>
-        </div>
-        
-        <div class="section">
-            <div class="collapsible" onclick="toggleCollapsible(this)">
-            <div class="section-header-row">
-                    <h2>Modules (` + fmt.Sprintf("%d", len(stateData.RootModule.ChildModules)) + ` total)</h2>
-                    <p class="section-description">Module hierarchy and organization</p>
-                </div>
-            </div>
-            <div class="collapsible-content">
-                ` + generateModulesHtml(stateData) + `
-            </div>
-        </div>
-    </div>
-    <div class="promo-message">
-        Want to visualize your Terraform plan and state changes over time and link them to your git history?<br>
-        <a href="https://cloudvic.com" class="promo-link">Try CloudVIC</a>
-    </div>
-</body>
-</html>`
-
-	return html
+// by rendering the page template
+func generateHtml(stateData *StateData, options Options) (string, error) {
+	return renderPageTemplate(stateData, options)
 }
 
 // generateStateOverviewHtml creates the state overview section
-func generateStateOverviewHtml(stateData *StateData) string {
+func generateStateOverviewHtml(stateData *StateData, options Options) string {
 	var html strings.Builder
 
 	html.WriteString(`<div class="summary">
@@ -310,10 +32,17 @@ func generateStateOverviewHtml(stateData *StateData) string {
 		</div>
 		<div class="summary-item">
 			<div class="summary-number">` + stateData.TerraformVersion + `</div>
-			<div class="summary-label">Terraform Version</div>
+			<div class="summary-label">` + stateData.Tool + ` Version</div>
 		</div>
 	</div>`)
 
+	// Note which resources the counts leave out
+	if stateData.Filter != nil {
+		html.WriteString(fmt.Sprintf(`<p class="section-description">Showing %d of %d resources; %d filtered out by %s</p>`,
+			len(stateData.Resources), stateData.Filter.Total, stateData.Filter.Excluded,
+			escapeHtml(strings.Join(stateData.Filter.Criteria, "; "))))
+	}
+
 	// Add resource type breakdown
 	if len(stateData.ResourceCounts) > 0 {
 		html.WriteString(`<div style="margin-top: 20px;">
@@ -338,26 +67,53 @@ func generateStateOverviewHtml(stateData *StateData) string {
 		for _, resourceType := range sortedTypes {
 			count := stateData.ResourceCounts[resourceType]
 			html.WriteString(fmt.Sprintf(`
-				<div style="padding: 5px 0; border-bottom: 1px solid #eee;">
-					<span style="font-weight: bold; color: #2c3e50;">%s:</span>
-					<span style="color: #3498db; margin-left: 10px;">%d</span>
+				<div style="padding: 5px 0; border-bottom: 1px solid var(--border);">
+					<span style="font-weight: bold; color: var(--heading);">%s:</span>
+					<span style="color: var(--accent); margin-left: 10px;">%d</span>
 				</div>`, resourceType, count))
 		}
 
 		html.WriteString(`</div></div>`)
 	}
 
+	// Add provider and schema version inventory
+	html.WriteString(generateProviderInventoryHtml(buildProviderInventory(stateData)))
+
+	// Add resources by account and region
+	html.WriteString(generateLocationHtml(groupResourcesByLocation(stateData)))
+
+	// Add tag compliance when a tag policy is configured
+	if len(options.TagPolicy.Required) > 0 || len(options.TagPolicy.Allowed) > 0 {
+		html.WriteString(generateTagComplianceHtml(evaluateTagCompliance(stateData, options.TagPolicy)))
+	}
+
+	// Add the cost estimate when a pricing catalog is loaded
+	if options.PricingCatalog != nil {
+		html.WriteString(generateCostHtml(estimateCosts(stateData, options.PricingCatalog)))
+	}
+
 	return html.String()
 }
 
 // generateResourcesHtml creates the resources section
-func generateResourcesHtml(stateData *StateData) string {
+func generateResourcesHtml(stateData *StateData, options Options) string {
+	if len(stateData.Resources) == 0 && stateData.Filter != nil {
+		return "<p>No resources match the current filter.</p>"
+	}
 	if len(stateData.Resources) == 0 {
 		return "<p>No resources found in state.</p>"
 	}
 
 	var html strings.Builder
-	html.WriteString("<div>")
+	html.WriteString(`<div class="filter-bar">
+			<input type="search" id="resource-search" placeholder="Filter by address or type" oninput="applyFilters(true)">
+			<select id="resource-mode" onchange="applyFilters(true)">
+				<option value="">All modes</option>
+				<option value="managed">Managed</option>
+				<option value="data">Data Sources</option>
+			</select>
+		</div>`)
+	html.WriteString(`<div id="resource-list">`)
 
 	for _, resource := range stateData.Resources {
 		modeClass := "managed"
@@ -365,11 +121,13 @@ func generateResourcesHtml(stateData *StateData) string {
 			modeClass = "data"
 		}
 
+		id := anchorID(resource.Address)
 		html.WriteString(fmt.Sprintf(`
-			<div class="resource-item %s">
+			<div class="resource-item %s" id="%s" data-address="%s" data-type="%s" data-mode="%s">
 				<div class="collapsible" onclick="toggleCollapsible(this)">
 					<div>%s</div>
 					<div class="resource-address">%s</div>
+					%s%s
 				</div>
 				<div class="collapsible-content">
 					<div class="resource-attributes">
@@ -378,8 +136,14 @@ func generateResourcesHtml(stateData *StateData) string {
 				</div>
 			</div>`,
 			modeClass,
+			id,
+			escapeHtml(resource.Address),
+			escapeHtml(resource.Type),
+			escapeHtml(resource.Mode),
 			formatResourceMode(resource.Mode),
 			resource.Address,
+			consoleLinkHtml(options.ConsoleLinks, resource),
+			anchorLinkHtml(id),
 			generateResourceAttributesHtml(resource)))
 	}
 
@@ -410,8 +174,10 @@ func generateResourceAttributesHtml(resource Resource) string {
 			<span class="attribute-value">%d</span>
 		</div>`, resource.SchemaVersion))
 
-	// Resource values
-	if len(resource.Values) > 0 {
+	// Resource values, grouped by the provider schema when one was given
+	if len(resource.Values) > 0 && resource.Schema != nil {
+		html.WriteString(generateSchemaAttributesHtml(resource))
+	} else if len(resource.Values) > 0 {
 		html.WriteString(`<div class="attribute-item">
 			<span class="attribute-key">Configuration:</span>
 		</div>`)
@@ -452,6 +218,72 @@ func generateResourceAttributesHtml(resource Resource) string {
 	return html.String()
 }
 
+// generateSchemaAttributesHtml lists a resource's values in two groups, the arguments that can
+// be configured and the attributes computed by the provider, with the schema description of
+// each attribute as hover text and deprecated attributes flagged
+func generateSchemaAttributesHtml(resource Resource) string {
+	var html strings.Builder
+	block := resource.Schema.Block
+
+	if block.Deprecated {
+		html.WriteString(`
+		<div class="attribute-item">
+			<span class="attribute-key">Deprecated:</span>
+			<span class="attribute-value">This resource type is deprecated by its provider</span>
+		</div>`)
+	}
+
+	keys := make([]string, 0, len(resource.Values))
+	for key := range resource.Values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var configured, computed []string
+	for _, key := range keys {
+		if block.isComputedOnly(key) {
+			computed = append(computed, key)
+		} else {
+			configured = append(configured, key)
+		}
+	}
+
+	for _, group := range []struct {
+		label string
+		keys  []string
+	}{{"Configured", configured}, {"Computed", computed}} {
+		if len(group.keys) == 0 {
+			continue
+		}
+		html.WriteString(fmt.Sprintf(`<div class="attribute-item">
+			<span class="attribute-key">%s:</span>
+		</div>`, group.label))
+
+		for _, key := range group.keys {
+			value := resource.Values[key]
+			cssClass := ""
+			valueStr := formatValue(value)
+			if isSensitiveValue(key, value, resource.SensitiveValues) {
+				cssClass = "attribute-sensitive"
+				valueStr = maskSensitiveValue(value)
+			}
+
+			deprecated := ""
+			if block.isDeprecated(key) {
+				deprecated = `<span class="attribute-deprecated">deprecated</span>`
+			}
+
+			html.WriteString(fmt.Sprintf(`
+				<div class="attribute-item %s" title="%s">
+					<span class="attribute-key">%s:</span>%s
+					<span class="attribute-value">%s</span>
+				</div>`, cssClass, escapeHtml(block.attributeSummary(key)), escapeHtml(key), deprecated, escapeHtml(valueStr)))
+		}
+	}
+
+	return html.String()
+}
+
 // generateOutputsHtml creates the outputs section
 func generateOutputsHtml(stateData *StateData) string {
 	if len(stateData.Outputs) == 0 {
@@ -472,11 +304,13 @@ func generateOutputsHtml(stateData *StateData) string {
 			valueStr = maskSensitiveValue(output.Value)
 		}
 
+		id := anchorID("output." + output.Name)
 		html.WriteString(fmt.Sprintf(`
-			<div class="resource-item %s">
+			<div class="resource-item %s" id="%s">
 				<div class="collapsible" onclick="toggleCollapsible(this)">
 					<div>Output</div>
 					<div class="resource-address">%s</div>
+					%s
 				</div>
 				<div class="collapsible-content">
 					<div class="resource-attributes">
@@ -496,7 +330,9 @@ func generateOutputsHtml(stateData *StateData) string {
 				</div>
 			</div>`,
 			cssClass,
+			id,
 			output.Name,
+			anchorLinkHtml(id),
 			formatValue(output.Type),
 			cssClass,
 			valueStr,
@@ -508,11 +344,14 @@ func generateOutputsHtml(stateData *StateData) string {
 }
 
 // generateModulesHtml creates the modules section
-func generateModulesHtml(stateData *StateData) string {
+func generateModulesHtml(stateData *StateData, options Options) string {
+	if len(stateData.RootModule.ChildModules) == 0 && stateData.Filter != nil {
+		return "<p>No modules match the current filter.</p>"
+	}
 	if len(stateData.RootModule.ChildModules) == 0 {
-		return `<div style="text-align: center; padding: 40px 20px; background-color: #f8f9fa; border-radius: 8px; margin: 20px 0;">
-			<h3 style="color: #2c3e50; margin-bottom: 15px;">No modules found</h3>
-			<p style="color: #6c757d; margin-bottom: 20px; font-size: 16px;">
+		return `<div style="text-align: center; padding: 40px 20px; background-color: var(--subtle-background); border-radius: 8px; margin: 20px 0;">
+			<h3 style="color: var(--heading); margin-bottom: 15px;">No modules found</h3>
+			<p style="color: var(--description); margin-bottom: 20px; font-size: 16px;">
 				This state file does not contain any child modules.
 			</p>
 		</div>`
@@ -523,7 +362,7 @@ func generateModulesHtml(stateData *StateData) string {
 
 	// Generate module hierarchy
 	for _, module := range stateData.RootModule.ChildModules {
-		html.WriteString(generateModuleHtml(module, 0))
+		html.WriteString(generateModuleHtml(module, 0, options))
 	}
 
 	html.WriteString("</div>")
@@ -531,7 +370,7 @@ func generateModulesHtml(stateData *StateData) string {
 }
 
 // generateModuleHtml creates HTML for a single module and its children
-func generateModuleHtml(module Module, depth int) string {
+func generateModuleHtml(module Module, depth int, options Options) string {
 	var html strings.Builder
 
 	// Calculate margin based on depth
@@ -540,16 +379,18 @@ func generateModuleHtml(module Module, depth int) string {
 	// Count total resources in this module and its children
 	totalResources := countModuleResources(module)
 
+	id := anchorID(module.Address)
 	html.WriteString(fmt.Sprintf(`
-		<div class="module-item" style="margin-left: %dpx;">
+		<div class="module-item" id="%s" style="margin-left: %dpx;">
 			<div class="collapsible" onclick="toggleCollapsible(this)">
 				<div>
 					<div class="module-address">%s</div>
 					<div class="module-resource-count">%d resources</div>
 				</div>
+				%s
 			</div>
 			<div class="collapsible-content">
-				<div class="resource-attributes">`, marginLeft, module.Address, totalResources))
+				<div class="resource-attributes">`, id, marginLeft, module.Address, totalResources, anchorLinkHtml(id)))
 
 	// Add resources from this module
 	if len(module.Resources) > 0 {
@@ -564,10 +405,11 @@ func generateModuleHtml(module Module, depth int) string {
 			}
 
 			html.WriteString(fmt.Sprintf(`
-				<div class="resource-item %s" style="margin-left: 20px;">
+				<div class="resource-item %s" id="%s" style="margin-left: 20px;">
 					<div class="collapsible" onclick="toggleCollapsible(this)">
 						<div>%s</div>
 						<div class="resource-address">%s</div>
+						%s%s
 					</div>
 					<div class="collapsible-content">
 						<div class="resource-attributes">
@@ -576,8 +418,11 @@ func generateModuleHtml(module Module, depth int) string {
 					</div>
 				</div>`,
 				modeClass,
+				moduleCardID(resource.Address),
 				formatResourceMode(resource.Mode),
 				resource.Address,
+				consoleLinkHtml(options.ConsoleLinks, resource),
+				anchorLinkHtml(moduleCardID(resource.Address)),
 				generateResourceAttributesHtml(resource)))
 		}
 	}
@@ -613,7 +458,7 @@ func generateModuleHtml(module Module, depth int) string {
 
 	// Add child modules recursively
 	for _, childModule := range module.ChildModules {
-		html.WriteString(generateModuleHtml(childModule, depth+1))
+		html.WriteString(generateModuleHtml(childModule, depth+1, options))
 	}
 
 	return html.String()
@@ -655,3 +500,35 @@ func formatValue(value interface{}) string {
 		return fmt.Sprintf("%v", v)
 	}
 }
+
+// anchorID returns a stable, URL-safe element ID for a resource, output or module address.
+// Characters other than letters, digits and _.-[] are percent-encoded as browsers do in
+// fragments, so distinct addresses such as x["a b"] and x["a_b"] never share an ID.
+func anchorID(address string) string {
+	var id strings.Builder
+	for _, b := range []byte(address) {
+		switch {
+		case b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b >= '0' && b <= '9', strings.IndexByte("_.-[]", b) >= 0:
+			id.WriteByte(b)
+		default:
+			fmt.Fprintf(&id, "%%%02X", b)
+		}
+	}
+	return id.String()
+}
+
+// moduleCardID returns the ID of a resource's card inside its module section. The ":" is
+// always encoded in anchor IDs, so it cannot clash with the card in the resource list.
+func moduleCardID(address string) string {
+	return "modules:" + anchorID(address)
+}
+
+// anchorLinkHtml renders the permalink shown in a card header
+func anchorLinkHtml(id string) string {
+	return fmt.Sprintf(`<a class="anchor-link" href="#%s" title="Link to this card" onclick="linkTo(event, '%s')">#</a>`, id, id)
+}
+
+// escapeHtml escapes text for safe inclusion in HTML
+func escapeHtml(text string) string {
+	return html.EscapeString(text)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"flag"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultTemplates holds the page templates shipped with the binary
+//
+//go:embed templates/default/*.tmpl
+var defaultTemplates embed.FS
+
+// pageTemplateName is the entry point every template directory must provide
+const pageTemplateName = "index.html.tmpl"
+
+// TemplateData is the data passed to page templates
+type TemplateData struct {
+	State    *StateData
+	Title    string
+	Logo     template.URL
+	Theme    string
+	Branding Branding
+	// Workspaces is empty unless the input has several workspaces
+	Workspaces []WorkspaceLink
+}
+
+// templateFuncs returns the helper functions available to page templates
+func templateFuncs(stateData *StateData, options Options) template.FuncMap {
+	return template.FuncMap{
+		// Page sections as rendered by the default template
+		"themeCss": func() template.CSS { return template.CSS(themeCss(options.Theme)) },
+		"customCss": func() template.CSS {
+			return template.CSS(strings.ReplaceAll(options.Branding.CustomCSS, "</style", `<\/style`))
+		},
+		"overviewHtml":  func() template.HTML { return template.HTML(generateStateOverviewHtml(stateData, options)) },
+		"resourcesHtml": func() template.HTML { return template.HTML(generateResourcesHtml(stateData, options)) },
+		"outputsHtml":   func() template.HTML { return template.HTML(generateOutputsHtml(stateData)) },
+		"modulesHtml":   func() template.HTML { return template.HTML(generateModulesHtml(stateData, options)) },
+		"attributesHtml": func(resource Resource) template.HTML {
+			return template.HTML(generateResourceAttributesHtml(resource))
+		},
+
+		// Masking and formatting
+		"isSensitive": func(resource Resource, key string) bool {
+			return isSensitiveValue(key, resource.Values[key], resource.SensitiveValues)
+		},
+		"mask":        maskSensitiveValue,
+		"formatValue": formatValue,
+		"formatMode":  formatResourceMode,
+		"moduleName":  moduleDisplayName,
+		"provider":    providerShortName,
+		"anchorID":    anchorID,
+		"join":        strings.Join,
+		"consoleURL":  options.ConsoleLinks.consoleURL,
+		"sparkline":   sparklineSvg,
+		"formatTime":  func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+
+		// Derived data
+		"graph": func(level string) (*ResourceGraph, error) { return buildResourceGraph(stateData, level) },
+	}
+}
+
+// loadPageTemplate parses the *.tmpl files of a template directory, or the built-in
+// default templates when dir is empty; name is the entry point the directory must provide
+func loadPageTemplate(dir, name string, funcs template.FuncMap) (*template.Template, error) {
+	page := template.New(name).Funcs(funcs)
+
+	if dir == "" {
+		return page.ParseFS(defaultTemplates, "templates/default/*.tmpl")
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("listing templates in %s: %v", dir, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no *.tmpl files found in %s", dir)
+	}
+
+	page, err = page.ParseFiles(files...)
+	if err != nil {
+		return nil, fmt.Errorf("parsing templates: %v", err)
+	}
+	if entry := page.Lookup(name); entry == nil || entry.Tree == nil {
+		return nil, fmt.Errorf("template directory %s has no %s", dir, name)
+	}
+
+	return page, nil
+}
+
+// renderPageTemplate renders the HTML page with the configured or default template
+func renderPageTemplate(stateData *StateData, options Options) (string, error) {
+	page, err := loadPageTemplate(options.TemplateDir, pageTemplateName, templateFuncs(stateData, options))
+	if err != nil {
+		return "", err
+	}
+
+	data := TemplateData{
+		State:      stateData,
+		Title:      options.Branding.pageTitle(),
+		Logo:       template.URL(options.Branding.Logo),
+		Theme:      options.Theme,
+		Branding:   options.Branding,
+		Workspaces: options.Workspaces,
+	}
+
+	var output bytes.Buffer
+	if err := page.ExecuteTemplate(&output, pageTemplateName, data); err != nil {
+		return "", fmt.Errorf("rendering template: %v", err)
+	}
+
+	return output.String(), nil
+}
+
+// runTemplateCommand runs the "template" subcommand
+func runTemplateCommand(args []string) error {
+	if len(args) == 0 || args[0] != "export" {
+		return fmt.Errorf("usage: terraform-state-visualizer template export -o <dir>")
+	}
+
+	flags := flag.NewFlagSet("template export", flag.ExitOnError)
+	outputDir := flags.String("o", "", "Directory to write the default template to (required)")
+	flags.Parse(args[1:])
+
+	if *outputDir == "" {
+		return fmt.Errorf("output directory is required")
+	}
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		return fmt.Errorf("creating %s: %v", *outputDir, err)
+	}
+
+	return fs.WalkDir(defaultTemplates, "templates/default", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		target := filepath.Join(*outputDir, entry.Name())
+		if _, err := os.Stat(target); err == nil {
+			return fmt.Errorf("%s already exists", target)
+		}
+
+		content, err := defaultTemplates.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, content, 0644); err != nil {
+			return fmt.Errorf("writing %s: %v", target, err)
+		}
+		fmt.Printf("Wrote %s\n", target)
+		return nil
+	})
+}
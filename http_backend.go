@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// HTTPBackend reads state from a server implementing the Terraform HTTP backend protocol.
+// It only ever sends GET requests, so state is never locked or written back.
+type HTTPBackend struct {
+	Address  string
+	Username string
+	Password string
+	Client   *http.Client
+}
+
+// StateLock is the lock information an HTTP backend returns while the state is locked
+type StateLock struct {
+	ID        string `json:"ID"`
+	Operation string `json:"Operation"`
+	Who       string `json:"Who"`
+	Created   string `json:"Created"`
+}
+
+// newHTTPBackend creates a backend client for a state address. Basic auth credentials
+// come from the URL or the TF_HTTP_USERNAME and TF_HTTP_PASSWORD environment
+// variables used by Terraform's own HTTP backend.
+func newHTTPBackend(address string) *HTTPBackend {
+	backend := &HTTPBackend{
+		Address:  address,
+		Username: os.Getenv("TF_HTTP_USERNAME"),
+		Password: os.Getenv("TF_HTTP_PASSWORD"),
+		Client:   &http.Client{Timeout: 2 * time.Minute},
+	}
+
+	if parsed, err := url.Parse(address); err == nil && parsed.User != nil {
+		backend.Username = parsed.User.Username()
+		backend.Password, _ = parsed.User.Password()
+		parsed.User = nil
+		backend.Address = parsed.String()
+	}
+
+	return backend
+}
+
+// fetchState downloads the current state
+func (b *HTTPBackend) fetchState() ([]byte, error) {
+	request, err := http.NewRequest(http.MethodGet, b.Address, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state address %s: %v", b.Address, err)
+	}
+	request.Header.Set("Accept", "application/json")
+	if b.Username != "" || b.Password != "" {
+		request.SetBasicAuth(b.Username, b.Password)
+	}
+
+	response, err := b.Client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("fetching state from %s: %v", b.Address, err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading state from %s: %v", b.Address, err)
+	}
+
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent, http.StatusNotFound:
+		return nil, fmt.Errorf("no state stored at %s", b.Address)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, fmt.Errorf("access to %s denied (%s); set TF_HTTP_USERNAME and TF_HTTP_PASSWORD for basic auth", b.Address, response.Status)
+	case http.StatusLocked:
+		// The protocol serves state while it is locked, but some servers refuse reads instead
+		return nil, fmt.Errorf("state at %s is locked%s", b.Address, describeLock(body))
+	default:
+		return nil, fmt.Errorf("fetching state from %s: unexpected status %s", b.Address, response.Status)
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, fmt.Errorf("no state stored at %s", b.Address)
+	}
+
+	// Servers may send a checksum of the state, which Terraform verifies too
+	if checksum := response.Header.Get("Content-MD5"); checksum != "" {
+		expected, err := base64.StdEncoding.DecodeString(checksum)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Content-MD5 header from %s: %v", b.Address, err)
+		}
+		if actual := md5.Sum(body); !bytes.Equal(expected, actual[:]) {
+			return nil, fmt.Errorf("state from %s does not match its Content-MD5 checksum", b.Address)
+		}
+	}
+
+	fmt.Fprintf(logOutput, "Fetched %d bytes of state from %s\n", len(body), b.Address)
+	return body, nil
+}
+
+// describeLock formats the lock information in a 423 Locked response, if any
+func describeLock(body []byte) string {
+	var lock StateLock
+	if err := json.Unmarshal(body, &lock); err != nil || lock.ID == "" {
+		return ""
+	}
+	description := fmt.Sprintf(" (lock %s", lock.ID)
+	if lock.Who != "" {
+		description += " held by " + lock.Who
+	}
+	if lock.Operation != "" {
+		description += " for " + lock.Operation
+	}
+	if lock.Created != "" {
+		description += " since " + lock.Created
+	}
+	return description + ")"
+}
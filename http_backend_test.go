@@ -0,0 +1,164 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testState = `{"version": 4, "serial": 1, "resources": []}`
+
+// stateServer is a stand-in for an HTTP backend that records the requests it receives
+type stateServer struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	requests []*http.Request
+}
+
+func newStateServer(t *testing.T, handler http.HandlerFunc) *stateServer {
+	t.Helper()
+	logOutput = io.Discard
+
+	server := &stateServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.mutex.Lock()
+		server.requests = append(server.requests, r)
+		server.mutex.Unlock()
+		handler(w, r)
+	}))
+	t.Cleanup(func() {
+		server.Close()
+		server.mutex.Lock()
+		defer server.mutex.Unlock()
+		for _, request := range server.requests {
+			if request.Method != http.MethodGet {
+				t.Errorf("backend sent %s %s; only GET is allowed", request.Method, request.URL.Path)
+			}
+		}
+	})
+	return server
+}
+
+func TestHTTPBackendBasicAuth(t *testing.T) {
+	tests := []struct {
+		name     string
+		userInfo string
+		username string
+		password string
+	}{
+		{name: "url", userInfo: "ci:from-url@"},
+		{name: "environment", username: "ci", password: "from-env"},
+		{name: "url overrides environment", userInfo: "ci:from-url@", username: "other", password: "from-env"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("TF_HTTP_USERNAME", test.username)
+			t.Setenv("TF_HTTP_PASSWORD", test.password)
+
+			wantPassword := "from-env"
+			if test.userInfo != "" {
+				wantPassword = "from-url"
+			}
+			server := newStateServer(t, func(w http.ResponseWriter, r *http.Request) {
+				username, password, ok := r.BasicAuth()
+				if !ok || username != "ci" || password != wantPassword {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				io.WriteString(w, testState)
+			})
+
+			address := strings.Replace(server.URL, "http://", "http://"+test.userInfo, 1) + "/state"
+			backend := newHTTPBackend(address)
+			if strings.Contains(backend.Address, "from-url") {
+				t.Errorf("address %s still holds the credentials", backend.Address)
+			}
+
+			data, err := backend.fetchState()
+			if err != nil {
+				t.Fatalf("fetchState: %v", err)
+			}
+			if string(data) != testState {
+				t.Errorf("fetchState returned %q, want %q", data, testState)
+			}
+		})
+	}
+}
+
+func TestHTTPBackendErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    string
+	}{
+		{
+			name:    "no content",
+			handler: func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) },
+			want:    "no state stored",
+		},
+		{
+			name:    "not found",
+			handler: func(w http.ResponseWriter, r *http.Request) { http.NotFound(w, r) },
+			want:    "no state stored",
+		},
+		{
+			name:    "unauthorized",
+			handler: func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusUnauthorized) },
+			want:    "set TF_HTTP_USERNAME and TF_HTTP_PASSWORD",
+		},
+		{
+			name: "locked",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusLocked)
+				io.WriteString(w, `{"ID": "f2e1c0d3", "Operation": "OperationTypeApply", "Who": "alice@ci", "Created": "2026-10-18T09:00:00Z"}`)
+			},
+			want: "is locked (lock f2e1c0d3 held by alice@ci for OperationTypeApply since 2026-10-18T09:00:00Z)",
+		},
+		{
+			name: "checksum mismatch",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				checksum := md5.Sum([]byte("some other state"))
+				w.Header().Set("Content-MD5", base64.StdEncoding.EncodeToString(checksum[:]))
+				io.WriteString(w, testState)
+			},
+			want: "does not match its Content-MD5 checksum",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("TF_HTTP_USERNAME", "")
+			t.Setenv("TF_HTTP_PASSWORD", "")
+			server := newStateServer(t, test.handler)
+
+			_, err := newHTTPBackend(server.URL + "/state").fetchState()
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("fetchState error = %v, want one containing %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestHTTPBackendChecksum(t *testing.T) {
+	t.Setenv("TF_HTTP_USERNAME", "")
+	t.Setenv("TF_HTTP_PASSWORD", "")
+	server := newStateServer(t, func(w http.ResponseWriter, r *http.Request) {
+		checksum := md5.Sum([]byte(testState))
+		w.Header().Set("Content-MD5", base64.StdEncoding.EncodeToString(checksum[:]))
+		io.WriteString(w, testState)
+	})
+
+	data, err := newHTTPBackend(server.URL + "/state").fetchState()
+	if err != nil {
+		t.Fatalf("fetchState: %v", err)
+	}
+	if string(data) != testState {
+		t.Errorf("fetchState returned %q, want %q", data, testState)
+	}
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// maxInputLayers bounds how many compression and archive layers are unwrapped, e.g. tar inside gzip
+const maxInputLayers = 4
+
+// Input formats detected from their leading bytes
+const (
+	inputPlain = "plain"
+	inputGzip  = "gzip"
+	inputZstd  = "zstd"
+	inputBzip2 = "bzip2"
+	inputTar   = "tar"
+	inputZip   = "zip"
+)
+
+// splitArchiveEntry splits an input like states.tar.gz#*/terraform.tfstate into the file
+// and the glob selecting an archive entry. A file whose name contains "#" is used as it is.
+func splitArchiveEntry(input string) (string, string) {
+	if _, err := os.Stat(input); err == nil {
+		return input, ""
+	}
+	if separator := strings.LastIndex(input, "#"); separator >= 0 {
+		return input[:separator], input[separator+1:]
+	}
+	return input, ""
+}
+
+// readLocalInput reads state from a local file, decompressing it and selecting the archive
+// entry named in the input. Archives are streamed; nothing is extracted to disk.
+func readLocalInput(input string) ([]byte, error) {
+	filePath, entry := splitArchiveEntry(input)
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %v", filePath, err)
+	}
+	defer file.Close()
+
+	data, err := readStateStream(file, filePath, entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %v", filePath, err)
+	}
+	return data, nil
+}
+
+// detectInputFormat identifies compression and archive formats by their magic bytes
+func detectInputFormat(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		return inputGzip
+	case bytes.HasPrefix(header, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return inputZstd
+	case bytes.HasPrefix(header, []byte("BZh")):
+		return inputBzip2
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")), bytes.HasPrefix(header, []byte("PK\x05\x06")):
+		return inputZip
+	case len(header) >= 262 && string(header[257:262]) == "ustar":
+		return inputTar
+	default:
+		return inputPlain
+	}
+}
+
+// readStateStream unwraps gzip, zstd and bzip2 compression and tar and zip archives until
+// plain content remains. entry is a glob selecting the archive entry; without one, the
+// archive must hold a single *.tfstate or *.json file.
+func readStateStream(source io.Reader, name, entry string) ([]byte, error) {
+	reader := bufio.NewReaderSize(source, 64*1024)
+
+	for layer := 0; layer < maxInputLayers; layer++ {
+		// Short inputs return fewer bytes and io.EOF, which is fine for detection
+		header, _ := reader.Peek(512)
+		format := detectInputFormat(header)
+
+		switch format {
+		case inputGzip:
+			decompressed, err := gzip.NewReader(reader)
+			if err != nil {
+				return nil, fmt.Errorf("reading gzip data: %v", err)
+			}
+			defer decompressed.Close()
+			reader = bufio.NewReaderSize(decompressed, 64*1024)
+		case inputZstd:
+			decompressed, err := zstd.NewReader(reader)
+			if err != nil {
+				return nil, fmt.Errorf("reading zstd data: %v", err)
+			}
+			defer decompressed.Close()
+			reader = bufio.NewReaderSize(decompressed, 64*1024)
+		case inputBzip2:
+			reader = bufio.NewReaderSize(bzip2.NewReader(reader), 64*1024)
+		case inputTar:
+			entryName, data, err := readTarEntry(tar.NewReader(reader), entry)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(logOutput, "Reading %s from %s\n", entryName, name)
+			// Entries may be compressed themselves, e.g. state.json.gz in a tarball
+			return readStateStream(bytes.NewReader(data), entryName, "")
+		case inputZip:
+			entryName, data, err := readZipEntry(source, layer, reader, entry)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(logOutput, "Reading %s from %s\n", entryName, name)
+			return readStateStream(bytes.NewReader(data), entryName, "")
+		default:
+			if entry != "" {
+				return nil, fmt.Errorf("cannot select entry '%s': %s is not a tar or zip archive", entry, name)
+			}
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %v", name, err)
+			}
+			return data, nil
+		}
+
+		fmt.Fprintf(logOutput, "Decompressing %s (%s)\n", name, format)
+	}
+
+	return nil, fmt.Errorf("%s has more than %d layers of compression", name, maxInputLayers)
+}
+
+// readZipEntry reads the selected entry of a zip archive. Zip needs random access, so a
+// local file is read in place and other streams are buffered in memory.
+func readZipEntry(source io.Reader, layer int, reader *bufio.Reader, pattern string) (string, []byte, error) {
+	var archive *zip.Reader
+	var err error
+	if file, ok := source.(*os.File); ok && layer == 0 {
+		info, statErr := file.Stat()
+		if statErr != nil {
+			return "", nil, statErr
+		}
+		archive, err = zip.NewReader(file, info.Size())
+	} else {
+		data, readErr := io.ReadAll(reader)
+		if readErr != nil {
+			return "", nil, fmt.Errorf("reading zip data: %v", readErr)
+		}
+		archive, err = zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("reading zip archive: %v", err)
+	}
+
+	var names []string
+	files := make(map[string]*zip.File)
+	for _, file := range archive.File {
+		if !file.FileInfo().IsDir() {
+			names = append(names, file.Name)
+			files[file.Name] = file
+		}
+	}
+
+	name, err := selectArchiveEntry(names, pattern)
+	if err != nil {
+		return "", nil, err
+	}
+
+	entry, err := files[name].Open()
+	if err != nil {
+		return "", nil, fmt.Errorf("opening %s: %v", name, err)
+	}
+	defer entry.Close()
+
+	data, err := io.ReadAll(entry)
+	if err != nil {
+		return "", nil, fmt.Errorf("reading %s: %v", name, err)
+	}
+	return name, data, nil
+}
+
+// readTarEntry reads the selected entry of a tar stream. The whole archive is scanned so
+// that a pattern matching several entries is reported instead of silently taking the first.
+func readTarEntry(archive *tar.Reader, pattern string) (string, []byte, error) {
+	var names []string
+	contents := make(map[string][]byte)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("reading tar archive: %v", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		names = append(names, header.Name)
+
+		// Only candidate entries are kept in memory
+		if matchesArchiveEntry(header.Name, pattern) {
+			data, err := io.ReadAll(archive)
+			if err != nil {
+				return "", nil, fmt.Errorf("reading %s: %v", header.Name, err)
+			}
+			contents[header.Name] = data
+		}
+	}
+
+	name, err := selectArchiveEntry(names, pattern)
+	if err != nil {
+		return "", nil, err
+	}
+	return name, contents[name], nil
+}
+
+// matchesArchiveEntry reports whether an entry may be selected by a pattern. Patterns
+// without a "/" also match the base name; without a pattern, state files are candidates.
+func matchesArchiveEntry(name, pattern string) bool {
+	if pattern == "" {
+		return isStateEntry(name)
+	}
+	if matched, _ := path.Match(pattern, name); matched {
+		return true
+	}
+	if !strings.Contains(pattern, "/") {
+		matched, _ := path.Match(pattern, path.Base(name))
+		return matched
+	}
+	return false
+}
+
+// isStateEntry reports whether an entry name looks like a state file, ignoring compression extensions
+func isStateEntry(name string) bool {
+	for _, extension := range []string{".gz", ".zst", ".bz2"} {
+		name = strings.TrimSuffix(name, extension)
+	}
+	return strings.HasSuffix(name, ".tfstate") || strings.HasSuffix(name, ".json")
+}
+
+// selectArchiveEntry picks the single entry matching a pattern. Without a pattern, a
+// *.tfstate entry is preferred over *.json ones, which may be plans.
+func selectArchiveEntry(names []string, pattern string) (string, error) {
+	var matches []string
+	for _, name := range names {
+		if matchesArchiveEntry(name, pattern) {
+			matches = append(matches, name)
+		}
+	}
+
+	if pattern == "" && len(matches) > 1 {
+		var states []string
+		for _, name := range matches {
+			if strings.Contains(name, ".tfstate") {
+				states = append(states, name)
+			}
+		}
+		if len(states) == 1 {
+			matches = states
+		}
+	}
+
+	switch {
+	case len(matches) == 1:
+		return matches[0], nil
+	case len(matches) > 1:
+		return "", fmt.Errorf("several archive entries match (%s); select one with archive#pattern", strings.Join(matches, ", "))
+	case pattern != "":
+		return "", fmt.Errorf("no archive entry matches '%s'", pattern)
+	default:
+		return "", fmt.Errorf("no *.tfstate or *.json entry in the archive; select one with archive#pattern")
+	}
+}
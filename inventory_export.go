@@ -0,0 +1,232 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// inventoryHeaders are the fixed leading columns of the inventory export
+var inventoryHeaders = []string{"address", "module", "type", "provider", "mode", "region", "account"}
+
+// buildInventoryRows returns one row per resource instance with the fixed columns
+// followed by the user-selected attribute columns, masking sensitive values
+func buildInventoryRows(stateData *StateData, columns []string) ([]string, [][]string, error) {
+	paths := make([]queryPath, len(columns))
+	for i, column := range columns {
+		// An attribute sharing a fixed column's name is selected as values.<name>
+		for _, header := range inventoryHeaders {
+			if column == header {
+				return nil, nil, fmt.Errorf("column '%s' is always exported; use values.%s for the attribute of that name", column, column)
+			}
+		}
+		node, err := parseQuery("values." + strings.TrimPrefix(column, "values."))
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing column '%s': %v", column, err)
+		}
+		path, ok := node.(queryPath)
+		if !ok {
+			return nil, nil, fmt.Errorf("column '%s' is not an attribute path", column)
+		}
+		paths[i] = path
+	}
+
+	headers := append(append([]string{}, inventoryHeaders...), columns...)
+
+	var rows [][]string
+	for _, resource := range stateData.Resources {
+		row := []string{
+			resource.Address,
+			moduleDisplayName(resource.ModuleAddress),
+			resource.Type,
+			providerShortName(resource.ProviderName),
+			resource.Mode,
+			resource.Region,
+			resource.Account,
+		}
+
+		record := maskedResourceRecord(resource)
+		for _, path := range paths {
+			row = append(row, formatQueryValue(path.evalMasked(record, resource)))
+		}
+
+		rows = append(rows, row)
+	}
+
+	return headers, rows, nil
+}
+
+// generateInventoryCSV renders the resource inventory as CSV
+func generateInventoryCSV(stateData *StateData, columns []string) (string, error) {
+	headers, rows, err := buildInventoryRows(stateData, columns)
+	if err != nil {
+		return "", err
+	}
+
+	var content strings.Builder
+	writer := csv.NewWriter(&content)
+	if err := writer.Write(headers); err != nil {
+		return "", err
+	}
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = csvText(cell)
+		}
+		if err := writer.Write(cells); err != nil {
+			return "", err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", err
+	}
+
+	return content.String(), nil
+}
+
+// csvText keeps spreadsheet applications from evaluating a cell as a formula by prefixing
+// cells starting with =, +, -, @ or a control character with a quote. XLSX cells are written
+// as inline strings, which are never evaluated, so they are left as they are.
+func csvText(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// generateInventoryXLSX renders the resource inventory as an Excel workbook
+func generateInventoryXLSX(stateData *StateData, columns []string) (string, error) {
+	headers, rows, err := buildInventoryRows(stateData, columns)
+	if err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+		{"xl/worksheets/sheet1.xml", xlsxSheet(headers, rows)},
+	}
+
+	for _, file := range files {
+		writer, err := archive.Create(file.name)
+		if err != nil {
+			return "", fmt.Errorf("creating %s: %v", file.name, err)
+		}
+		if _, err := writer.Write([]byte(file.content)); err != nil {
+			return "", fmt.Errorf("writing %s: %v", file.name, err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return "", fmt.Errorf("finishing workbook: %v", err)
+	}
+
+	return buffer.String(), nil
+}
+
+// xlsxSheet renders the worksheet XML with a bold, frozen and filterable header row
+func xlsxSheet(headers []string, rows [][]string) string {
+	var sheet strings.Builder
+
+	lastCell := fmt.Sprintf("%s%d", xlsxColumnName(len(headers)-1), len(rows)+1)
+
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>
+<sheetData>`)
+
+	writeRow := func(index int, cells []string, style int) {
+		sheet.WriteString(fmt.Sprintf(`<row r="%d">`, index))
+		for i, cell := range cells {
+			sheet.WriteString(fmt.Sprintf(`<c r="%s%d" t="inlineStr" s="%d"><is><t xml:space="preserve">%s</t></is></c>`,
+				xlsxColumnName(i), index, style, html.EscapeString(xmlText(cell))))
+		}
+		sheet.WriteString(`</row>`)
+	}
+
+	writeRow(1, headers, 1)
+	for i, row := range rows {
+		writeRow(i+2, row, 0)
+	}
+
+	sheet.WriteString(fmt.Sprintf(`</sheetData>
+<autoFilter ref="A1:%s"/>
+</worksheet>`, lastCell))
+
+	return sheet.String()
+}
+
+// xmlText removes the control characters XML 1.0 does not allow, even escaped, such as
+// NUL or ESC in attribute values
+func xmlText(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t', r == '\n', r == '\r':
+			return r
+		case r < 0x20, r == 0xFFFE, r == 0xFFFF, r >= 0xD800 && r <= 0xDFFF:
+			return -1
+		default:
+			return r
+		}
+	}, text)
+}
+
+// xlsxColumnName converts a zero-based column index to a spreadsheet column name (A, B, ..., AA)
+func xlsxColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// Static parts of the XLSX package
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+</Types>`
+
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Resources" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+	xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>
+</styleSheet>`
+)
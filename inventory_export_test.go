@@ -0,0 +1,121 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestBuildInventoryRowsMasking(t *testing.T) {
+	stateData := loadQueryTestState(t)
+
+	_, rows, err := buildInventoryRows(stateData, []string{"tags.CostCenter", "user_data", "settings.connection", "values.name"})
+	if err != nil {
+		t.Fatalf("buildInventoryRows: %v", err)
+	}
+
+	want := map[string][]string{
+		"aws_instance.web":               {"cc-42", "#!/b...ter2", "", ""},
+		"aws_security_group.web":         {"", "", "", `web "public"`},
+		"data.aws_region.current":        {"", "", "", "us-east-1"},
+		"module.db.aws_db_instance.main": {"", "", "post...w@db", ""},
+	}
+	for _, row := range rows {
+		got := row[len(inventoryHeaders):]
+		if strings.Join(got, "|") != strings.Join(want[row[0]], "|") {
+			t.Errorf("%s columns = %q, want %q", row[0], got, want[row[0]])
+		}
+	}
+}
+
+func TestBuildInventoryRowsInsideMaskedValue(t *testing.T) {
+	logOutput = io.Discard
+	stateData := &StateData{Resources: []Resource{{
+		Address:         "aws_lambda_function.api",
+		Values:          map[string]interface{}{"environment": map[string]interface{}{"variables": map[string]interface{}{"STAGE": "prod"}}},
+		SensitiveValues: map[string]interface{}{"environment": true},
+	}}}
+
+	_, rows, err := buildInventoryRows(stateData, []string{"environment.variables.STAGE", "environment.missing"})
+	if err != nil {
+		t.Fatalf("buildInventoryRows: %v", err)
+	}
+	if got := rows[0][len(inventoryHeaders):]; got[0] != "***" || got[1] != "" {
+		t.Errorf("columns = %q, want [*** \"\"]", got)
+	}
+}
+
+func TestBuildInventoryRowsFixedColumns(t *testing.T) {
+	for _, column := range inventoryHeaders {
+		if _, _, err := buildInventoryRows(&StateData{}, []string{column}); err == nil {
+			t.Errorf("column %s was accepted", column)
+		}
+	}
+}
+
+func TestGenerateInventoryCSVFormulas(t *testing.T) {
+	logOutput = io.Discard
+	stateData := &StateData{Resources: []Resource{{
+		Address: "aws_instance.web",
+		Values: map[string]interface{}{"tags": map[string]interface{}{
+			"Owner": "=HYPERLINK(\"http://evil\")", "Team": "+1", "Budget": "-5", "Contact": "@ops", "CostCenter": "cc-42",
+		}},
+	}}}
+
+	content, err := generateInventoryCSV(stateData, []string{"tags.Owner", "tags.Team", "tags.Budget", "tags.Contact", "tags.CostCenter"})
+	if err != nil {
+		t.Fatalf("generateInventoryCSV: %v", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(content)).ReadAll()
+	if err != nil {
+		t.Fatalf("parsing CSV: %v", err)
+	}
+	got := records[1][len(inventoryHeaders):]
+	want := []string{"'=HYPERLINK(\"http://evil\")", "'+1", "'-5", "'@ops", "cc-42"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("cells = %q, want %q", got, want)
+	}
+}
+
+func TestGenerateInventoryXLSXControlCharacters(t *testing.T) {
+	logOutput = io.Discard
+	stateData := &StateData{Resources: []Resource{{
+		Address: "aws_instance.web",
+		Values:  map[string]interface{}{"description": "bell\a nul\x00 tab\t esc\x1b end"},
+	}}}
+
+	content, err := generateInventoryXLSX(stateData, []string{"description"})
+	if err != nil {
+		t.Fatalf("generateInventoryXLSX: %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader([]byte(content)), int64(len(content)))
+	if err != nil {
+		t.Fatalf("reading workbook: %v", err)
+	}
+	for _, file := range archive.File {
+		if file.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		reader, _ := file.Open()
+		sheet, _ := io.ReadAll(reader)
+		decoder := xml.NewDecoder(bytes.NewReader(sheet))
+		for {
+			_, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("worksheet is not valid XML: %v", err)
+			}
+		}
+		if !strings.Contains(string(sheet), "bell nul tab\t esc end") {
+			t.Errorf("worksheet lost text around the control characters:\n%s", sheet)
+		}
+		return
+	}
+	t.Fatal("workbook has no worksheet")
+}
@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"runtime"
+	"strings"
 )
 
 // Version information - set during build
@@ -15,11 +17,67 @@ var (
 	GitCommit = "unknown"
 )
 
+// Output formats supported by the -format flag
+const (
+	formatHTML    = "html"
+	formatDot     = "dot"
+	formatMermaid = "mermaid"
+	formatSVG     = "svg"
+	formatText    = "text"
+	formatTable   = "table"
+	formatCSV     = "csv"
+	formatXLSX    = "xlsx"
+)
+
+// Default output paths used when -o is not given
+const (
+	defaultOutputFile     = "state-visualization.html"
+	defaultSVGOutputFile  = "state-visualization.svg"
+	defaultXLSXOutputFile = "state-inventory.xlsx"
+)
+
+// Options holds the rendering options selected on the command line
+type Options struct {
+	Format     string
+	GraphLevel string
+	Columns    []string
+	TagPolicy  TagPolicy
+	// PricingCatalog enables the cost estimate section when set
+	PricingCatalog *PricingCatalog
+	// ConsoleLinks maps resource types to cloud console URLs for the HTML page
+	ConsoleLinks *ConsoleLinkTable
+	// Schemas describes the attributes of resource types when a provider schema file is given
+	Schemas  *ProviderSchemas
+	Theme    string
+	Branding Branding
+	// TemplateDir holds custom page templates; empty uses the built-in template
+	TemplateDir string
+	// Filter selects the resources that are rendered
+	Filter   ResourceFilter
+	Terminal terminalSettings
+	// Workspaces is the workspace switcher shown on pages of a multi-workspace input
+	Workspaces []WorkspaceLink
+}
+
+// logOutput receives progress messages; it is switched to stderr when the
+// rendered output itself is written to stdout
+var logOutput io.Writer = os.Stdout
+
 func main() {
+	// Subcommands take their own flags
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		if err := runSubcommand(os.Args[1], os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Define command line flags
 	var inputFile = flag.String("i", "", "Input file path (required)")
 	var outputFile = flag.String("o", "state-visualization.html", "Output HTML file path (default: state-visualization.html)")
 	var outputFileLong = flag.String("output-html-path", "state-visualization.html", "Output HTML file path (default: state-visualization.html)")
+	var configPath = defineConfigFlags(flag.CommandLine)
 	var showVersion = flag.Bool("v", false, "Show version information")
 	var showHelp = flag.Bool("h", false, "Show help information")
 
@@ -45,28 +103,98 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Configuration file values are the defaults; flags given on the command line override them
+	config, err := loadConfigWithFlags(flag.CommandLine, *configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		showUsage()
+		os.Exit(1)
+	}
+
+	options, err := buildOptions(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := validateOptions(options); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		showUsage()
+		os.Exit(1)
+	}
+
 	// Determine output file (prefer -o over --output-html-path if both are set)
 	finalOutputFile := *outputFile
-	if *outputFileLong != "state-visualization.html" && *outputFile == "state-visualization.html" {
+	if *outputFileLong != defaultOutputFile && *outputFile == defaultOutputFile {
 		finalOutputFile = *outputFileLong
 	}
 
+	// Text formats are written to stdout unless an output file is given
+	if !isFlagSet("o") && !isFlagSet("output-html-path") {
+		finalOutputFile = defaultOutputForFormat(options.Format)
+	}
+	if finalOutputFile == "-" {
+		logOutput = os.Stderr
+		options.Terminal = detectTerminal(os.Stdout)
+	}
+
 	// Display input and output files
-	fmt.Printf("Input file: %s\n", *inputFile)
-	fmt.Printf("Output file: %s\n", finalOutputFile)
+	fmt.Fprintf(logOutput, "Input file: %s\n", displayInput(*inputFile))
+	fmt.Fprintf(logOutput, "Output file: %s\n", finalOutputFile)
+
+	// An input selecting all workspaces is rendered once per workspace
+	inputs, err := expandWorkspaces(*inputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 
 	// Process the files
-	if err := processStateFile(*inputFile, finalOutputFile); err != nil {
+	if len(inputs) > 1 {
+		err = processWorkspaces(inputs, finalOutputFile, options)
+	} else {
+		err = processStateFile(inputs[0].Input, finalOutputFile, options)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error processing state file: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+// runSubcommand dispatches to the subcommand with the given name
+func runSubcommand(name string, args []string) error {
+	switch name {
+	case "browse":
+		return runBrowseCommand(args)
+	case "query":
+		return runQueryCommand(args)
+	case "tags":
+		return runTagsCommand(args)
+	case "cost":
+		return runCostCommand(args)
+	case "timeline":
+		return runTimelineCommand(args)
+	case "template":
+		return runTemplateCommand(args)
+	case "config":
+		return runConfigCommand(args)
+	default:
+		return fmt.Errorf("unknown command '%s'", name)
+	}
+}
+
 func validateInput(inputFile string) error {
 	if inputFile == "" {
 		return fmt.Errorf("input file is required")
 	}
 
+	// Remote state is checked when it is fetched
+	if isRemoteInput(inputFile) {
+		return nil
+	}
+
+	// Archive inputs may name an entry after "#"
+	inputFile, _ = splitArchiveEntry(inputFile)
+
 	// Check if input file exists
 	if _, err := os.Stat(inputFile); os.IsNotExist(err) {
 		return fmt.Errorf("input file '%s' does not exist", inputFile)
@@ -80,65 +208,203 @@ func validateInput(inputFile string) error {
 	return nil
 }
 
-func processStateFile(inputFile, outputFile string) error {
-	fmt.Println("\nProcessing files:")
+func validateOptions(options Options) error {
+	switch options.Format {
+	case formatHTML, formatDot, formatMermaid, formatSVG, formatText, formatTable, formatCSV, formatXLSX:
+	default:
+		return fmt.Errorf("unsupported output format '%s'", options.Format)
+	}
 
-	// Display file information
-	fmt.Printf("Input file: %s\n", inputFile)
-	fmt.Printf("Output file: %s\n", outputFile)
+	if !validGraphLevel(options.GraphLevel) {
+		return fmt.Errorf("unsupported graph level '%s'", options.GraphLevel)
+	}
 
-	// Read JSON file
-	jsonData, err := readJSONFile(inputFile)
-	if err != nil {
-		return fmt.Errorf("reading JSON file: %v", err)
+	if err := validateTheme(options.Theme); err != nil {
+		return err
 	}
 
-	// Parse JSON
-	var stateData interface{}
-	if err := json.Unmarshal(jsonData, &stateData); err != nil {
-		return fmt.Errorf("parsing state JSON: %v", err)
+	return nil
+}
+
+// isFlagSet reports whether a flag was explicitly given on the command line
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
+}
 
-	fmt.Println("Successfully parsed JSON file!")
-	fmt.Printf("JSON contains %d bytes of data\n", len(jsonData))
+// defaultOutputForFormat returns the output path used when none is given
+func defaultOutputForFormat(format string) string {
+	switch format {
+	case formatHTML:
+		return defaultOutputFile
+	case formatSVG:
+		return defaultSVGOutputFile
+	case formatXLSX:
+		return defaultXLSXOutputFile
+	default:
+		return "-"
+	}
+}
 
-	// Parse the state data
-	parsedState, err := parseStateData(stateData)
+func processStateFile(inputFile, outputFile string, options Options) error {
+	fmt.Fprintln(logOutput, "\nProcessing files:")
+
+	// Display file information
+	fmt.Fprintf(logOutput, "Input file: %s\n", displayInput(inputFile))
+	fmt.Fprintf(logOutput, "Output file: %s\n", outputFile)
+
+	parsedState, err := loadStateFile(inputFile)
 	if err != nil {
-		return fmt.Errorf("parsing state data: %v", err)
+		return err
+	}
+	if err := prepareState(parsedState, options); err != nil {
+		return err
 	}
 
-	fmt.Printf("Successfully parsed state data!\n")
-	fmt.Printf("Found %d resources and %d outputs\n", len(parsedState.Resources), len(parsedState.Outputs))
+	if err := writeStateFile(parsedState, outputFile, options); err != nil {
+		return err
+	}
+	fmt.Fprintln(logOutput, "\nFile processing completed!")
+	return nil
+}
 
-	// Generate HTML from the parsed state data
-	htmlContent := generateHtml(parsedState)
-	fmt.Printf("Generated HTML content (%d characters)\n", len(htmlContent))
+// writeStateFile renders parsed state data in the requested format and writes it to outputFile
+func writeStateFile(parsedState *StateData, outputFile string, options Options) error {
+	// Render the parsed state data in the requested format
+	content, err := renderState(parsedState, options)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(logOutput, "Generated %s content (%d characters)\n", options.Format, len(content))
 
-	// Write HTML to output file
-	if err := writeHtmlFile(outputFile, htmlContent); err != nil {
-		return fmt.Errorf("writing HTML file: %v", err)
+	// Write rendered content to output file
+	if err := writeOutputFile(outputFile, content); err != nil {
+		return fmt.Errorf("writing output file: %v", err)
 	}
 
-	fmt.Printf("Successfully wrote HTML to: %s\n", outputFile)
-	fmt.Println("\nFile processing completed!")
+	if outputFile != "-" {
+		fmt.Fprintf(logOutput, "Successfully wrote %s to: %s\n", options.Format, outputFile)
+	}
 	return nil
 }
 
-func writeHtmlFile(filePath, htmlContent string) error {
-	err := os.WriteFile(filePath, []byte(htmlContent), 0644)
+// loadStateFile reads and parses a Terraform state JSON file
+func loadStateFile(inputFile string) (*StateData, error) {
+	// Read the local file or fetch the remote state
+	jsonData, err := readStateInput(inputFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading state: %v", err)
+	}
+
+	parsedState, err := parseStateJSON(jsonData)
 	if err != nil {
-		return fmt.Errorf("failed to write HTML file %s: %v", filePath, err)
+		return nil, err
+	}
+
+	if parsedState.Encrypted {
+		fmt.Fprintln(logOutput, "Decrypted OpenTofu state")
 	}
+	fmt.Fprintln(logOutput, "Successfully parsed JSON file!")
+	fmt.Fprintf(logOutput, "JSON contains %d bytes of data\n", len(jsonData))
+	fmt.Fprintf(logOutput, "Successfully parsed state data!\n")
+	fmt.Fprintf(logOutput, "Found %d resources and %d outputs\n", len(parsedState.Resources), len(parsedState.Outputs))
+
+	return parsedState, nil
+}
+
+// prepareState applies the resource filter and provider schemas of the options, which every
+// output of a state goes through so they filter and mask alike
+func prepareState(stateData *StateData, options Options) error {
+	if err := applyResourceFilter(stateData, options.Filter); err != nil {
+		return err
+	}
+	applyProviderSchemas(stateData, options.Schemas)
 	return nil
 }
 
-func readJSONFile(filePath string) ([]byte, error) {
-	data, err := os.ReadFile(filePath)
+// loadSubcommandState loads a state for a subcommand and prepares it with prepareState
+func loadSubcommandState(inputFile string, options Options) (*StateData, error) {
+	stateData, err := loadStateFile(inputFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := prepareState(stateData, options); err != nil {
+		return nil, err
+	}
+	return stateData, nil
+}
+
+// parseStateJSON parses state JSON, either terraform show -json output or a raw state file
+func parseStateJSON(jsonData []byte) (*StateData, error) {
+	var stateData interface{}
+	if err := json.Unmarshal(jsonData, &stateData); err != nil {
+		return nil, fmt.Errorf("parsing state JSON: %v", err)
+	}
+
+	parsedState, err := parseStateData(stateData)
+	if err != nil {
+		return nil, fmt.Errorf("parsing state data: %v", err)
+	}
+	return parsedState, nil
+}
+
+// renderState renders the parsed state data in the format selected by the options
+func renderState(stateData *StateData, options Options) (string, error) {
+	switch options.Format {
+	case formatText:
+		return generateTextTree(stateData, options.Terminal), nil
+	case formatTable:
+		return generateTextTable(stateData, options.Terminal), nil
+	case formatCSV:
+		return generateInventoryCSV(stateData, options.Columns)
+	case formatXLSX:
+		return generateInventoryXLSX(stateData, options.Columns)
+	case formatDot, formatMermaid, formatSVG:
+		graph, err := buildResourceGraph(stateData, options.GraphLevel)
+		if err != nil {
+			return "", fmt.Errorf("building resource graph: %v", err)
+		}
+		switch options.Format {
+		case formatDot:
+			return generateDot(graph), nil
+		case formatMermaid:
+			return generateMermaid(graph), nil
+		default:
+			return generateSvg(graph, "Terraform State"), nil
+		}
+	default:
+		return generateHtml(stateData, options)
+	}
+}
+
+// writeOutputFile writes rendered content to a file, or to stdout when the path is "-"
+func writeOutputFile(filePath, content string) error {
+	if filePath == "-" {
+		_, err := io.WriteString(os.Stdout, content)
+		return err
+	}
+
+	err := os.WriteFile(filePath, []byte(content), 0644)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file %s: %v", filePath, err)
+		return fmt.Errorf("failed to write file %s: %v", filePath, err)
 	}
-	return data, nil
+	return nil
 }
 
 func showVersionInfo() {
@@ -156,12 +422,60 @@ func showHelpInfo() {
 	fmt.Println("Usage:")
 	fmt.Println("  terraform-state-visualizer -i <input-file> [-o <output-file>]")
 	fmt.Println("  terraform-state-visualizer -i <input-file> [--output-html-path <output-file>]")
+	fmt.Println("  terraform-state-visualizer browse -i <input-file>")
+	fmt.Println("  terraform-state-visualizer query -i <input-file> [-fields <fields>] [-format table|json|csv] <expression>")
+	fmt.Println("  terraform-state-visualizer tags -i <input-file> [-required-tags <tags>] [-allowed-tag-values <values>] [-config <file>]")
+	fmt.Println("  terraform-state-visualizer cost -i <input-file> [-pricing <catalog-file>] [-config <file>]")
+	fmt.Println("  terraform-state-visualizer timeline -i <snapshot-glob> [-o <output-file>]")
+	fmt.Println("  terraform-state-visualizer timeline -git-repo <dir> [-git-path terraform.tfstate] [-o <output-file>]")
+	fmt.Println("  terraform-state-visualizer template export -o <dir>")
+	fmt.Println("  terraform-state-visualizer config print [-config <file>]")
+	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("  browse                   Browse the state in a full-screen terminal UI")
+	fmt.Println("  query                    Filter resources with an expression and print selected fields")
+	fmt.Println("  tags                     Report resources missing required tags or using disallowed values")
+	fmt.Println("  cost                     Estimate monthly cost from a local pricing catalog")
+	fmt.Println("  timeline                 Compare a series of state snapshots and render an HTML timeline")
+	fmt.Println("  template export          Write the default page templates to a directory for customizing")
+	fmt.Println("  config print             Show the effective configuration from .tfviz.yaml and flags")
+	fmt.Println()
+	fmt.Println("  Every command except template export accepts -config <file> and -schema <file>; the")
+	fmt.Println("  redaction, ignore and schema settings of the configuration apply to all of them.")
 	fmt.Println()
 	fmt.Println("Options:")
-	fmt.Println("  -i, -input string        Input Terraform state JSON file (required)")
+	fmt.Println("  -i, -input string        Input Terraform state JSON file (optionally compressed or archived, with")
+	fmt.Println("                           archive#entry-glob), project directory, http(s):// state URL,")
+	fmt.Println("                           s3://bucket/key or tfc://organization/workspace (required)")
 	fmt.Println("  -o, -output string       Output HTML file path (default: state-visualization.html)")
 	fmt.Println("  --output-html-path string")
 	fmt.Println("                           Output HTML file path (alternative to -o)")
+	fmt.Println("  -format string           Output format: html, dot, mermaid, svg, text, table, csv, xlsx (default: html)")
+	fmt.Println("                           dot, mermaid, text, table and csv are written to stdout unless -o is given")
+	fmt.Println("  -graph-level string      Graph granularity for dot/mermaid/svg: resource, type, module (default: resource)")
+	fmt.Println("  -columns string          Comma-separated attribute columns for csv/xlsx (e.g. tags.CostCenter,instance_type)")
+	fmt.Println("  -required-tags string    Comma-separated required tags; adds a Tag Compliance section to the HTML")
+	fmt.Println("  -allowed-tag-values string")
+	fmt.Println("                           Allowed tag values, e.g. Environment=dev|stage|prod")
+	fmt.Println("  -include-type string     Only render these resource types (comma-separated globs, e.g. aws_iam_*)")
+	fmt.Println("  -exclude-type string     Leave out these resource types (comma-separated globs)")
+	fmt.Println("  -module string           Only render resources in these modules and their children (root for the root module)")
+	fmt.Println("  -include-address string  Only render these addresses (comma-separated globs, or /regex/)")
+	fmt.Println("  -no-data-sources         Leave out data sources")
+	fmt.Println("  -cost                    Add an estimated monthly cost section to the HTML")
+	fmt.Println("  -pricing string          Pricing catalog JSON file for cost estimates (implies -cost)")
+	fmt.Println("  -console-links string    JSON file with extra console link templates for the HTML page")
+	fmt.Println("  -schema string           Provider schema JSON from terraform providers schema -json; groups attributes")
+	fmt.Println("                           into configured and computed, adds descriptions and masks sensitive ones")
+	fmt.Println("  -theme string            HTML theme: auto, light, dark, high-contrast (default: auto)")
+	fmt.Println("  -css string              CSS file to include after the theme styles")
+	fmt.Println("  -logo string             Logo image file or URL shown next to the page title")
+	fmt.Println("  -title string            Page title (default: Terraform State)")
+	fmt.Println("  -footer string           Footer text shown at the bottom of the page")
+	fmt.Println("  -no-promo                Omit the CloudVIC promo message")
+	fmt.Println("  -template string         Directory with custom page templates (see: template export)")
+	fmt.Println("  -redaction string        Sensitive value redaction: partial, full, none (default: partial)")
+	fmt.Println("  -config string           Configuration file (default: nearest .tfviz.yaml)")
 	fmt.Println("  -v, -version             Show version information")
 	fmt.Println("  -h, -help                Show this help information")
 	fmt.Println()
@@ -169,6 +483,17 @@ func showHelpInfo() {
 	fmt.Println("  terraform-state-visualizer -i state.json")
 	fmt.Println("  terraform-state-visualizer -i state.json -o state-visualization.html")
 	fmt.Println("  terraform-state-visualizer -i state.json --output-html-path my-state.html")
+	fmt.Println("  terraform-state-visualizer -i state.json -format dot | dot -Tpng -o graph.png")
+	fmt.Println("  terraform-state-visualizer -i state.json -format mermaid -graph-level module")
+	fmt.Println("  terraform-state-visualizer -i state.json -format svg -o architecture.svg")
+	fmt.Println("  terraform-state-visualizer -i state.json -format table")
+	fmt.Println("  terraform-state-visualizer -i ./project -o state.html")
+	fmt.Println("  terraform-state-visualizer -i 'ci-artifacts.tar.gz#prod/terraform.tfstate'")
+	fmt.Println("  terraform-state-visualizer -i state.json -format xlsx -columns tags.CostCenter,tags.Owner")
+	fmt.Println("  terraform-state-visualizer query -i state.json 'type == \"aws_instance\" && values.instance_type =~ \"t2.*\"'")
+	fmt.Println()
+	fmt.Println("Environment:")
+	fmt.Println("  TFVIZ_STATE_PASSPHRASE   Passphrase for OpenTofu state encrypted with the pbkdf2 key provider")
 	fmt.Println()
 	fmt.Println("For more information, visit: https://github.com/cloudvic-org/terraform-state-visualizer")
 }
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Tools that write state files
+const (
+	toolTerraform = "Terraform"
+	toolOpenTofu  = "OpenTofu"
+)
+
+// statePassphraseVariable holds the passphrase of OpenTofu's pbkdf2 key provider
+const statePassphraseVariable = "TFVIZ_STATE_PASSPHRASE"
+
+// openTofuRegistry is the provider registry used by OpenTofu
+const openTofuRegistry = "registry.opentofu.org"
+
+// isEncryptedState reports whether decoded JSON is a state file encrypted by OpenTofu
+func isEncryptedState(stateMap map[string]interface{}) bool {
+	_, hasData := stateMap["encrypted_data"].(string)
+	_, hasVersion := stateMap["encryption_version"].(string)
+	return hasData && hasVersion
+}
+
+// pbkdf2Metadata is the key derivation metadata the pbkdf2 key provider stores with the state
+type pbkdf2Metadata struct {
+	Salt         []byte `json:"salt"`
+	Iterations   int    `json:"iterations"`
+	HashFunction string `json:"hash_function"`
+	KeyLength    int    `json:"key_length"`
+}
+
+// decryptOpenTofuState decrypts a state encrypted with OpenTofu's pbkdf2 key provider and
+// aes_gcm method, using the passphrase from TFVIZ_STATE_PASSPHRASE. Each stored key
+// provider is tried, so states written during a key rotation can be read with either passphrase.
+func decryptOpenTofuState(stateMap map[string]interface{}) ([]byte, error) {
+	if version := stateMap["encryption_version"]; version != "v0" {
+		return nil, fmt.Errorf("unsupported OpenTofu encryption version '%v'", version)
+	}
+
+	passphrase := os.Getenv(statePassphraseVariable)
+	if passphrase == "" {
+		return nil, fmt.Errorf("the state is encrypted by OpenTofu; set %s to the pbkdf2 passphrase", statePassphraseVariable)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(stateMap["encrypted_data"].(string))
+	if err != nil {
+		return nil, fmt.Errorf("decoding encrypted state: %v", err)
+	}
+
+	meta, _ := stateMap["meta"].(map[string]interface{})
+	providers := make([]string, 0, len(meta))
+	for provider := range meta {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+
+	var unsupported []string
+	for _, provider := range providers {
+		// Keys look like key_provider.pbkdf2.<name>
+		if !strings.HasPrefix(provider, "key_provider.pbkdf2.") {
+			unsupported = append(unsupported, provider)
+			continue
+		}
+
+		encoded, _ := meta[provider].(string)
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s metadata: %v", provider, err)
+		}
+		var metadata pbkdf2Metadata
+		if err := json.Unmarshal(raw, &metadata); err != nil {
+			return nil, fmt.Errorf("parsing %s metadata: %v", provider, err)
+		}
+
+		key, err := deriveStateKey(passphrase, metadata)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", provider, err)
+		}
+		if plaintext, err := decryptAESGCM(key, data); err == nil {
+			return plaintext, nil
+		}
+	}
+
+	if len(unsupported) > 0 && len(unsupported) == len(providers) {
+		return nil, fmt.Errorf("the state is encrypted with %s; only the pbkdf2 key provider is supported, use tofu show -json instead",
+			strings.Join(unsupported, ", "))
+	}
+	return nil, fmt.Errorf("cannot decrypt the state with the passphrase in %s", statePassphraseVariable)
+}
+
+// deriveStateKey derives the encryption key from the passphrase as the pbkdf2 key provider does
+func deriveStateKey(passphrase string, metadata pbkdf2Metadata) ([]byte, error) {
+	var hashFunction func() hash.Hash
+	switch metadata.HashFunction {
+	case "sha256":
+		hashFunction = sha256.New
+	case "sha512", "":
+		hashFunction = sha512.New
+	default:
+		return nil, fmt.Errorf("unsupported hash function '%s'", metadata.HashFunction)
+	}
+	if len(metadata.Salt) == 0 || metadata.Iterations <= 0 || metadata.KeyLength <= 0 {
+		return nil, fmt.Errorf("incomplete key derivation metadata")
+	}
+	return pbkdf2.Key(hashFunction, passphrase, metadata.Salt, metadata.Iterations, metadata.KeyLength)
+}
+
+// decryptAESGCM decrypts data written by the aes_gcm method: the nonce followed by the sealed state
+func decryptAESGCM(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted state is too short")
+	}
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+}
+
+// detectStateTool reports whether a state was written by OpenTofu, recognized by
+// providers from the OpenTofu registry
+func detectStateTool(state *StateData) string {
+	for _, resource := range state.Resources {
+		if parseProviderAddress(resource.ProviderName).Hostname == openTofuRegistry {
+			return toolOpenTofu
+		}
+	}
+	return toolTerraform
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProviderInfo describes a provider configuration referenced by resources
+type ProviderInfo struct {
+	Hostname  string
+	Namespace string
+	Name      string
+	Alias     string
+	Resources int
+}
+
+// Key returns the provider address including the alias, e.g. "hashicorp/aws.west"
+func (p ProviderInfo) Key() string {
+	key := p.Name
+	if p.Namespace != "" {
+		key = p.Namespace + "/" + p.Name
+	}
+	if p.Alias != "" {
+		key += "." + p.Alias
+	}
+	return key
+}
+
+// registryAddress returns the provider source address without the alias, e.g.
+// "registry.terraform.io/hashicorp/aws"
+func (p ProviderInfo) registryAddress() string {
+	var parts []string
+	for _, part := range []string{p.Hostname, p.Namespace, p.Name} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// SchemaVersionUsage records how many resources of a type use each schema version
+type SchemaVersionUsage struct {
+	Type     string
+	Versions map[int]int
+}
+
+// Mixed reports whether resources of the type were written with different schema versions
+func (u SchemaVersionUsage) Mixed() bool {
+	return len(u.Versions) > 1
+}
+
+// ProviderInventory lists the providers and schema versions used in a state
+type ProviderInventory struct {
+	Providers      []ProviderInfo
+	SchemaVersions []SchemaVersionUsage
+}
+
+// parseProviderAddress splits a provider reference into hostname, namespace, name and alias.
+// It accepts the JSON output form ("registry.terraform.io/hashicorp/aws"), the raw state form
+// (`provider["registry.terraform.io/hashicorp/aws"].west`) and the legacy form ("provider.aws.west").
+func parseProviderAddress(providerName string) ProviderInfo {
+	info := ProviderInfo{}
+	address := providerName
+
+	if strings.HasPrefix(address, `provider["`) {
+		end := strings.Index(address, `"]`)
+		if end < 0 {
+			return ProviderInfo{Name: "unknown"}
+		}
+		info.Alias = strings.TrimPrefix(address[end+2:], ".")
+		address = address[len(`provider["`):end]
+	} else if strings.HasPrefix(address, "provider.") {
+		parts := strings.SplitN(strings.TrimPrefix(address, "provider."), ".", 2)
+		address = parts[0]
+		if len(parts) == 2 {
+			info.Alias = parts[1]
+		}
+	}
+
+	parts := strings.Split(address, "/")
+	switch len(parts) {
+	case 3:
+		info.Hostname, info.Namespace, info.Name = parts[0], parts[1], parts[2]
+	case 2:
+		info.Namespace, info.Name = parts[0], parts[1]
+	default:
+		info.Name = parts[len(parts)-1]
+	}
+	if info.Name == "" {
+		info.Name = "unknown"
+	}
+
+	return info
+}
+
+// buildProviderInventory counts resources per provider and schema versions per resource type
+func buildProviderInventory(stateData *StateData) *ProviderInventory {
+	providers := make(map[string]*ProviderInfo)
+	versions := make(map[string]map[int]int)
+
+	for _, resource := range stateData.Resources {
+		info := parseProviderAddress(resource.ProviderName)
+		existing, exists := providers[info.Key()]
+		if !exists {
+			existing = &info
+			providers[info.Key()] = existing
+		}
+		existing.Resources++
+
+		resourceType := resource.Type
+		if resource.Mode == "data" {
+			resourceType = "data." + resource.Type
+		}
+		if versions[resourceType] == nil {
+			versions[resourceType] = make(map[int]int)
+		}
+		versions[resourceType][resource.SchemaVersion]++
+	}
+
+	inventory := &ProviderInventory{}
+	for _, info := range providers {
+		inventory.Providers = append(inventory.Providers, *info)
+	}
+	sort.Slice(inventory.Providers, func(i, j int) bool {
+		return inventory.Providers[i].Key() < inventory.Providers[j].Key()
+	})
+
+	for resourceType, counts := range versions {
+		inventory.SchemaVersions = append(inventory.SchemaVersions, SchemaVersionUsage{Type: resourceType, Versions: counts})
+	}
+	sort.Slice(inventory.SchemaVersions, func(i, j int) bool {
+		return inventory.SchemaVersions[i].Type < inventory.SchemaVersions[j].Type
+	})
+
+	return inventory
+}
+
+// formatSchemaVersions describes a schema version distribution, e.g. "v0 ×3, v1 ×1"
+func formatSchemaVersions(versions map[int]int) string {
+	var sorted []int
+	for version := range versions {
+		sorted = append(sorted, version)
+	}
+	sort.Ints(sorted)
+
+	parts := make([]string, len(sorted))
+	for i, version := range sorted {
+		parts[i] = fmt.Sprintf("v%d &times;%d", version, versions[version])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// generateProviderInventoryHtml renders the providers and schema versions section of the state overview
+func generateProviderInventoryHtml(inventory *ProviderInventory) string {
+	if len(inventory.Providers) == 0 {
+		return ""
+	}
+
+	var html strings.Builder
+	html.WriteString(`<div style="margin-top: 20px;">
+			<h3>Providers</h3>
+			<div style="margin-top: 10px;">`)
+
+	for _, provider := range inventory.Providers {
+		details := []string{"name: " + escapeHtml(provider.Name)}
+		if provider.Namespace != "" {
+			details = append([]string{"namespace: " + escapeHtml(provider.Namespace)}, details...)
+		}
+		if provider.Alias != "" {
+			details = append(details, "alias: "+escapeHtml(provider.Alias))
+		}
+		if provider.Hostname != "" {
+			details = append(details, "registry: "+escapeHtml(provider.Hostname))
+		}
+
+		html.WriteString(fmt.Sprintf(`
+				<div style="padding: 5px 0; border-bottom: 1px solid var(--border);">
+					<span style="font-weight: bold; color: var(--heading);">%s:</span>
+					<span style="color: var(--accent); margin-left: 10px;">%d resources</span>
+					<span style="color: var(--muted); margin-left: 10px; font-size: 0.9em;">%s</span>
+				</div>`, escapeHtml(provider.Key()), provider.Resources, strings.Join(details, " &middot; ")))
+	}
+
+	html.WriteString(`</div>`)
+
+	var mixed []string
+	for _, usage := range inventory.SchemaVersions {
+		if usage.Mixed() {
+			mixed = append(mixed, usage.Type)
+		}
+	}
+	if len(mixed) > 0 {
+		html.WriteString(fmt.Sprintf(`
+			<div class="attribute-item attribute-sensitive" style="margin-top: 10px;">
+				<span class="attribute-key">Mixed schema versions:</span>
+				<span class="attribute-value">%s have resources with different schema versions; some instances have not been upgraded yet and will be on the next refresh or apply</span>
+			</div>`, escapeHtml(strings.Join(mixed, ", "))))
+	}
+
+	html.WriteString(`<h4>Schema Versions by Type</h4><div style="margin-top: 10px;">`)
+	for _, usage := range inventory.SchemaVersions {
+		color := "var(--accent)"
+		if usage.Mixed() {
+			color = "var(--bad)"
+		}
+		html.WriteString(fmt.Sprintf(`
+				<div style="padding: 5px 0; border-bottom: 1px solid var(--border);">
+					<span style="font-weight: bold; color: var(--heading);">%s:</span>
+					<span style="color: %s; margin-left: 10px;">%s</span>
+				</div>`, escapeHtml(usage.Type), color, formatSchemaVersions(usage.Versions)))
+	}
+	html.WriteString(`</div></div>`)
+
+	return html.String()
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProviderSchemas holds the output of terraform providers schema -json (or tofu providers schema -json)
+type ProviderSchemas struct {
+	FormatVersion string                    `json:"format_version"`
+	Providers     map[string]ProviderSchema `json:"provider_schemas"`
+}
+
+// ProviderSchema holds the resource and data source schemas of one provider
+type ProviderSchema struct {
+	ResourceSchemas   map[string]ResourceSchema `json:"resource_schemas"`
+	DataSourceSchemas map[string]ResourceSchema `json:"data_source_schemas"`
+}
+
+// ResourceSchema is the schema of a resource type or data source
+type ResourceSchema struct {
+	Version int         `json:"version"`
+	Block   SchemaBlock `json:"block"`
+}
+
+// SchemaBlock describes the attributes and nested blocks of a resource or block
+type SchemaBlock struct {
+	Attributes  map[string]SchemaAttribute `json:"attributes"`
+	BlockTypes  map[string]SchemaBlockType `json:"block_types"`
+	Description string                     `json:"description"`
+	Deprecated  bool                       `json:"deprecated"`
+}
+
+// SchemaBlockType is a nested block, such as ebs_block_device
+type SchemaBlockType struct {
+	NestingMode string      `json:"nesting_mode"`
+	Block       SchemaBlock `json:"block"`
+}
+
+// SchemaAttribute describes one attribute
+type SchemaAttribute struct {
+	Type        interface{} `json:"type"`
+	Description string      `json:"description"`
+	Required    bool        `json:"required"`
+	Optional    bool        `json:"optional"`
+	Computed    bool        `json:"computed"`
+	Sensitive   bool        `json:"sensitive"`
+	Deprecated  bool        `json:"deprecated"`
+	// NestedType is set instead of Type for attributes with nested attributes
+	NestedType *struct {
+		Attributes  map[string]SchemaAttribute `json:"attributes"`
+		NestingMode string                     `json:"nesting_mode"`
+	} `json:"nested_type"`
+}
+
+// loadProviderSchemas reads a provider schema file; it may be compressed like state inputs
+func loadProviderSchemas(path string) (*ProviderSchemas, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := readLocalInput(path)
+	if err != nil {
+		return nil, err
+	}
+	schemas := &ProviderSchemas{}
+	if err := json.Unmarshal(data, schemas); err != nil {
+		return nil, fmt.Errorf("parsing provider schema %s: %v", path, err)
+	}
+	if len(schemas.Providers) == 0 {
+		return nil, fmt.Errorf("%s has no provider schemas (expected the output of terraform providers schema -json)", path)
+	}
+	return schemas, nil
+}
+
+// lookup returns the schema of a resource. Providers are matched by their full address first,
+// then by namespace and name, so schemas from either the Terraform or the OpenTofu registry fit.
+func (s *ProviderSchemas) lookup(resource Resource) *ResourceSchema {
+	if s == nil {
+		return nil
+	}
+
+	find := func(provider ProviderSchema) *ResourceSchema {
+		schemas := provider.ResourceSchemas
+		if resource.Mode == "data" {
+			schemas = provider.DataSourceSchemas
+		}
+		if schema, ok := schemas[resource.Type]; ok {
+			return &schema
+		}
+		return nil
+	}
+
+	if provider, ok := s.Providers[resource.ProviderName]; ok {
+		return find(provider)
+	}
+
+	wanted := parseProviderAddress(resource.ProviderName)
+	names := make([]string, 0, len(s.Providers))
+	for name := range s.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		candidate := parseProviderAddress(name)
+		if candidate.Namespace == wanted.Namespace && candidate.Name == wanted.Name {
+			return find(s.Providers[name])
+		}
+	}
+	return nil
+}
+
+// applyProviderSchemas attaches the schema of each resource and marks the attributes the
+// schema declares sensitive, so they are masked even when the state has no sensitive_values.
+// A nested block containing a sensitive attribute is masked as a whole.
+func applyProviderSchemas(state *StateData, schemas *ProviderSchemas) {
+	if schemas == nil {
+		return
+	}
+
+	apply := func(resources []Resource) int {
+		covered := 0
+		for i := range resources {
+			resource := &resources[i]
+			schema := schemas.lookup(*resource)
+			if schema == nil {
+				continue
+			}
+			covered++
+			resource.Schema = schema
+
+			for key := range resource.Values {
+				if !schema.Block.isSensitive(key) {
+					continue
+				}
+				if resource.SensitiveValues == nil {
+					resource.SensitiveValues = make(map[string]interface{})
+				}
+				resource.SensitiveValues[key] = true
+			}
+		}
+		return covered
+	}
+
+	covered := apply(state.Resources)
+	apply(state.RootModule.Resources)
+	// Modules are passed by value but share their resource slices with the state tree
+	walkModules(state.RootModule.ChildModules, func(module Module, parent string) {
+		apply(module.Resources)
+	})
+
+	fmt.Fprintf(logOutput, "Provider schema covers %d of %d resources\n", covered, len(state.Resources))
+}
+
+// isSensitive reports whether an attribute or nested block is, or contains, a sensitive attribute
+func (b SchemaBlock) isSensitive(key string) bool {
+	if attribute, ok := b.Attributes[key]; ok {
+		return attribute.containsSensitive()
+	}
+	if blockType, ok := b.BlockTypes[key]; ok {
+		for name := range blockType.Block.Attributes {
+			if blockType.Block.isSensitive(name) {
+				return true
+			}
+		}
+		for name := range blockType.Block.BlockTypes {
+			if blockType.Block.isSensitive(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containsSensitive reports whether an attribute or one of its nested attributes is sensitive
+func (a SchemaAttribute) containsSensitive() bool {
+	if a.Sensitive {
+		return true
+	}
+	if a.NestedType != nil {
+		for _, nested := range a.NestedType.Attributes {
+			if nested.containsSensitive() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isComputedOnly reports whether a value is set by the provider rather than the configuration.
+// Nested blocks are always written in configuration.
+func (b SchemaBlock) isComputedOnly(key string) bool {
+	attribute, ok := b.Attributes[key]
+	return ok && attribute.Computed && !attribute.Optional && !attribute.Required
+}
+
+// attributeSummary describes an attribute for hover text, e.g. "list(string), optional: The AMI to use"
+func (b SchemaBlock) attributeSummary(key string) string {
+	var kind, description string
+	if attribute, ok := b.Attributes[key]; ok {
+		kind = typeName(attribute.Type)
+		if attribute.NestedType != nil {
+			kind = attribute.NestedType.NestingMode + " of objects"
+		}
+		switch {
+		case attribute.Required:
+			kind += ", required"
+		case attribute.Optional && attribute.Computed:
+			kind += ", optional, computed"
+		case attribute.Optional:
+			kind += ", optional"
+		case attribute.Computed:
+			kind += ", computed"
+		}
+		if attribute.Sensitive {
+			kind += ", sensitive"
+		}
+		description = attribute.Description
+	} else if blockType, ok := b.BlockTypes[key]; ok {
+		kind = blockType.NestingMode + " block"
+		description = blockType.Block.Description
+	} else {
+		return "not in the provider schema"
+	}
+
+	if description = strings.TrimSpace(description); description != "" {
+		return kind + ": " + description
+	}
+	return kind
+}
+
+// isDeprecated reports whether the schema marks an attribute or nested block as deprecated
+func (b SchemaBlock) isDeprecated(key string) bool {
+	if attribute, ok := b.Attributes[key]; ok {
+		return attribute.Deprecated
+	}
+	if blockType, ok := b.BlockTypes[key]; ok {
+		return blockType.Block.Deprecated
+	}
+	return false
+}
+
+// typeName formats a schema type such as "string" or ["list","string"] as Terraform writes it
+func typeName(schemaType interface{}) string {
+	switch t := schemaType.(type) {
+	case string:
+		return t
+	case []interface{}:
+		if len(t) == 2 {
+			if kind, ok := t[0].(string); ok {
+				switch kind {
+				case "object":
+					return "object"
+				case "tuple":
+					return "tuple"
+				default:
+					return kind + "(" + typeName(t[1]) + ")"
+				}
+			}
+		}
+	}
+	return "dynamic"
+}
@@ -0,0 +1,724 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Output formats of the query subcommand
+const (
+	queryFormatTable = "table"
+	queryFormatJSON  = "json"
+	queryFormatCSV   = "csv"
+)
+
+// defaultQueryFields are projected when no -fields flag is given
+var defaultQueryFields = []string{"address", "type", "provider", "mode"}
+
+// queryNode is a node of a parsed query expression
+type queryNode interface {
+	eval(record map[string]interface{}) interface{}
+}
+
+// queryLiteral is a string, number, boolean or null literal
+type queryLiteral struct {
+	value interface{}
+}
+
+// queryPath looks up a field of the resource record
+type queryPath struct {
+	segments []interface{}
+}
+
+// queryNot negates its operand
+type queryNot struct {
+	operand queryNode
+}
+
+// queryLogical combines two operands with && or ||
+type queryLogical struct {
+	operator    string
+	left, right queryNode
+}
+
+// queryComparison compares two operands
+type queryComparison struct {
+	operator    string
+	left, right queryNode
+	pattern     *regexp.Regexp
+}
+
+// queryToken is a lexical token of a query expression
+type queryToken struct {
+	kind  string
+	value string
+}
+
+// queryParser is a recursive descent parser for query expressions
+type queryParser struct {
+	tokens   []queryToken
+	position int
+}
+
+// runQueryCommand runs the "query" subcommand
+func runQueryCommand(args []string) error {
+	flags := flag.NewFlagSet("query", flag.ExitOnError)
+	inputFile := flags.String("i", "", "Input file path (required)")
+	fields := flags.String("fields", strings.Join(defaultQueryFields, ","), "Comma-separated fields to output (e.g. address,values.instance_type)")
+	format := flags.String("format", queryFormatTable, "Output format: table, json, csv")
+	outputFile := flags.String("o", "-", "Output file path (default: stdout)")
+	configPath := defineSubcommandFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: terraform-state-visualizer query -i <input-file> [-config <file>] [-fields <fields>] [-format table|json|csv] [expression]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if err := validateInput(*inputFile); err != nil {
+		return err
+	}
+
+	switch *format {
+	case queryFormatTable, queryFormatJSON, queryFormatCSV:
+	default:
+		return fmt.Errorf("unsupported query output format '%s'", *format)
+	}
+
+	expression := strings.Join(flags.Args(), " ")
+	filter, err := parseQuery(expression)
+	if err != nil {
+		return fmt.Errorf("parsing query: %v", err)
+	}
+
+	options, err := loadSubcommandOptions(flags, *configPath)
+	if err != nil {
+		return err
+	}
+
+	logOutput = os.Stderr
+	stateData, err := loadSubcommandState(*inputFile, options)
+	if err != nil {
+		return err
+	}
+
+	projection := splitList(*fields)
+
+	results, err := runQuery(stateData, filter, projection)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(logOutput, "Query matched %d of %d resources\n", len(results), len(stateData.Resources))
+
+	var content string
+	switch *format {
+	case queryFormatJSON:
+		content, err = formatQueryJSON(projection, results)
+	case queryFormatCSV:
+		content, err = formatQueryCSV(projection, results)
+	default:
+		settings := terminalSettings{}
+		if *outputFile == "-" {
+			settings = detectTerminal(os.Stdout)
+		}
+		content = renderTable(queryHeaders(projection), formatQueryRows(results), settings)
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeOutputFile(*outputFile, content)
+}
+
+// runQuery filters the state's resources with a query and projects the selected fields
+func runQuery(stateData *StateData, filter queryNode, fields []string) ([][]interface{}, error) {
+	paths := make([]queryPath, len(fields))
+	for i, field := range fields {
+		node, err := parseQuery(field)
+		if err != nil {
+			return nil, fmt.Errorf("parsing field '%s': %v", field, err)
+		}
+		path, ok := node.(queryPath)
+		if !ok {
+			return nil, fmt.Errorf("field '%s' is not a field path", field)
+		}
+		paths[i] = path
+	}
+
+	var results [][]interface{}
+	for _, resource := range stateData.Resources {
+		// Filters see the masked values too, so comparisons cannot probe sensitive data
+		record := maskedResourceRecord(resource)
+		if filter != nil && !queryTruthy(filter.eval(record)) {
+			continue
+		}
+
+		row := make([]interface{}, len(paths))
+		for i, path := range paths {
+			row[i] = path.evalMasked(record, resource)
+		}
+		results = append(results, row)
+	}
+
+	return results, nil
+}
+
+// resourceRecord converts a resource into the normalized record queries run against
+func resourceRecord(resource Resource) map[string]interface{} {
+	dependsOn := make([]interface{}, len(resource.DependsOn))
+	for i, dep := range resource.DependsOn {
+		dependsOn[i] = dep
+	}
+
+	values := resource.Values
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+
+	return map[string]interface{}{
+		"address":        resource.Address,
+		"mode":           resource.Mode,
+		"type":           resource.Type,
+		"name":           resource.Name,
+		"index":          resource.Index,
+		"module":         resource.ModuleAddress,
+		"provider":       providerShortName(resource.ProviderName),
+		"provider_name":  resource.ProviderName,
+		"schema_version": float64(resource.SchemaVersion),
+		"region":         resource.Region,
+		"account":        resource.Account,
+		"depends_on":     dependsOn,
+		"values":         values,
+	}
+}
+
+// maskedResourceRecord returns the record of a resource with sensitive values masked at every
+// level, for output that must not reveal them
+func maskedResourceRecord(resource Resource) map[string]interface{} {
+	record := resourceRecord(resource)
+	values := make(map[string]interface{}, len(resource.Values))
+	for key := range resource.Values {
+		values[key] = maskedResourceValue(resource, key)
+	}
+	record["values"] = values
+	return record
+}
+
+// lookupResourceValue reads a nested value such as "root_block_device[0].volume_size"
+// from a resource's values, returning nil when the path does not exist
+func lookupResourceValue(resource Resource, path string) interface{} {
+	node, err := parseQuery("values." + path)
+	if err != nil {
+		return nil
+	}
+	queryPath, ok := node.(queryPath)
+	if !ok {
+		return nil
+	}
+	return queryPath.eval(resourceRecord(resource))
+}
+
+// parseQuery parses a query expression; an empty expression matches every resource
+func parseQuery(expression string) (queryNode, error) {
+	if strings.TrimSpace(expression) == "" {
+		return nil, nil
+	}
+
+	tokens, err := tokenizeQuery(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &queryParser{tokens: tokens}
+	node, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if parser.position < len(parser.tokens) {
+		return nil, fmt.Errorf("unexpected '%s'", parser.tokens[parser.position].value)
+	}
+
+	return node, nil
+}
+
+// tokenizeQuery splits a query expression into tokens
+func tokenizeQuery(expression string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(expression)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '"' || r == '\'':
+			// String literal with backslash escapes
+			var value strings.Builder
+			j := i + 1
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				value.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated string starting at position %d", i)
+			}
+			tokens = append(tokens, queryToken{kind: "string", value: value.String()})
+			i = j + 1
+
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			j := i + 1
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || (runes[j] == '.' && j+1 < len(runes) && unicode.IsDigit(runes[j+1]))) {
+				j++
+			}
+			tokens = append(tokens, queryToken{kind: "number", value: string(runes[i:j])})
+			i = j
+
+		case unicode.IsLetter(r) || r == '_':
+			j := i + 1
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_' || runes[j] == '-') {
+				j++
+			}
+			tokens = append(tokens, queryToken{kind: "ident", value: string(runes[i:j])})
+			i = j
+
+		default:
+			// Operators and punctuation, longest match first
+			matched := false
+			for _, operator := range []string{"&&", "||", "==", "!=", "=~", "!~", "<=", ">=", "<", ">", "!", "(", ")", "[", "]", "."} {
+				if strings.HasPrefix(string(runes[i:]), operator) {
+					tokens = append(tokens, queryToken{kind: "op", value: operator})
+					i += len([]rune(operator))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character '%c' at position %d", r, i)
+			}
+		}
+	}
+
+	return tokens, nil
+}
+
+// peek returns the next token without consuming it
+func (p *queryParser) peek() (queryToken, bool) {
+	if p.position >= len(p.tokens) {
+		return queryToken{}, false
+	}
+	return p.tokens[p.position], true
+}
+
+// accept consumes the next token if it is the given operator
+func (p *queryParser) accept(operator string) bool {
+	if token, ok := p.peek(); ok && token.kind == "op" && token.value == operator {
+		p.position++
+		return true
+	}
+	return false
+}
+
+// parseOr parses expressions joined by ||
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = queryLogical{operator: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseAnd parses expressions joined by &&
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = queryLogical{operator: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseUnary parses negations and comparisons
+func (p *queryParser) parseUnary() (queryNode, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return queryNot{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+// parseComparison parses an operand optionally compared with another operand
+func (p *queryParser) parseComparison() (queryNode, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	token, ok := p.peek()
+	if !ok || token.kind != "op" {
+		return left, nil
+	}
+
+	switch token.value {
+	case "==", "!=", "=~", "!~", "<", "<=", ">", ">=":
+		p.position++
+	default:
+		return left, nil
+	}
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := queryComparison{operator: token.value, left: left, right: right}
+	if token.value == "=~" || token.value == "!~" {
+		literal, ok := right.(queryLiteral)
+		pattern, isString := literal.value.(string)
+		if !ok || !isString {
+			return nil, fmt.Errorf("right side of %s must be a string pattern", token.value)
+		}
+		comparison.pattern, err = regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %v", pattern, err)
+		}
+	}
+
+	return comparison, nil
+}
+
+// parseOperand parses a literal, a field path or a parenthesized expression
+func (p *queryParser) parseOperand() (queryNode, error) {
+	token, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	p.position++
+
+	switch token.kind {
+	case "string":
+		return queryLiteral{value: token.value}, nil
+	case "number":
+		number, err := strconv.ParseFloat(token.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s'", token.value)
+		}
+		return queryLiteral{value: number}, nil
+	case "ident":
+		switch token.value {
+		case "true":
+			return queryLiteral{value: true}, nil
+		case "false":
+			return queryLiteral{value: false}, nil
+		case "null":
+			return queryLiteral{value: nil}, nil
+		}
+		return p.parsePath(token.value)
+	case "op":
+		if token.value == "(" {
+			node, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if !p.accept(")") {
+				return nil, fmt.Errorf("missing closing parenthesis")
+			}
+			return node, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unexpected '%s'", token.value)
+}
+
+// parsePath parses the remaining segments of a field path such as values.tags["Name"]
+func (p *queryParser) parsePath(first string) (queryNode, error) {
+	path := queryPath{segments: []interface{}{first}}
+
+	for {
+		switch {
+		case p.accept("."):
+			token, ok := p.peek()
+			if !ok || token.kind != "ident" {
+				return nil, fmt.Errorf("expected field name after '.'")
+			}
+			p.position++
+			path.segments = append(path.segments, token.value)
+		case p.accept("["):
+			token, ok := p.peek()
+			if !ok {
+				return nil, fmt.Errorf("expected index after '['")
+			}
+			p.position++
+			switch token.kind {
+			case "number":
+				index, err := strconv.Atoi(token.value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index '%s'", token.value)
+				}
+				path.segments = append(path.segments, index)
+			case "string":
+				path.segments = append(path.segments, token.value)
+			default:
+				return nil, fmt.Errorf("unexpected '%s' in index", token.value)
+			}
+			if !p.accept("]") {
+				return nil, fmt.Errorf("missing closing bracket")
+			}
+		default:
+			return path, nil
+		}
+	}
+}
+
+func (l queryLiteral) eval(record map[string]interface{}) interface{} {
+	return l.value
+}
+
+func (p queryPath) eval(record map[string]interface{}) interface{} {
+	var current interface{} = record
+
+	for _, segment := range p.segments {
+		switch s := segment.(type) {
+		case string:
+			object, ok := current.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			current = object[s]
+		case int:
+			list, ok := current.([]interface{})
+			if !ok || s < 0 || s >= len(list) {
+				return nil
+			}
+			current = list[s]
+		}
+	}
+
+	return current
+}
+
+// evalMasked evaluates the path against a masked record. A path leading into a value that
+// was masked as a whole yields the masked marker rather than nothing, so hidden values show as hidden.
+func (p queryPath) evalMasked(masked map[string]interface{}, resource Resource) interface{} {
+	if value := p.eval(masked); value != nil {
+		return value
+	}
+	if value := p.eval(resourceRecord(resource)); value != nil {
+		return maskSensitiveValue(value)
+	}
+	return nil
+}
+
+func (n queryNot) eval(record map[string]interface{}) interface{} {
+	return !queryTruthy(n.operand.eval(record))
+}
+
+func (l queryLogical) eval(record map[string]interface{}) interface{} {
+	left := queryTruthy(l.left.eval(record))
+	if l.operator == "&&" {
+		return left && queryTruthy(l.right.eval(record))
+	}
+	return left || queryTruthy(l.right.eval(record))
+}
+
+func (c queryComparison) eval(record map[string]interface{}) interface{} {
+	left := c.left.eval(record)
+	right := c.right.eval(record)
+
+	// Negated operators match when no list element matches
+	switch c.operator {
+	case "!=":
+		return !queryCompareAny(left, right, "==", nil)
+	case "!~":
+		return !queryCompareAny(left, right, "=~", c.pattern)
+	default:
+		return queryCompareAny(left, right, c.operator, c.pattern)
+	}
+}
+
+// queryCompareAny compares a value, or any element of a list value, with the right operand
+func queryCompareAny(left, right interface{}, operator string, pattern *regexp.Regexp) bool {
+	if list, ok := left.([]interface{}); ok {
+		if _, rightIsList := right.([]interface{}); !rightIsList {
+			for _, item := range list {
+				if queryCompare(item, right, operator, pattern) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return queryCompare(left, right, operator, pattern)
+}
+
+// queryCompare compares two scalar values, numerically when both are numbers
+func queryCompare(left, right interface{}, operator string, pattern *regexp.Regexp) bool {
+	if operator == "=~" {
+		if left == nil {
+			return false
+		}
+		return pattern.MatchString(formatQueryValue(left))
+	}
+
+	leftNumber, leftIsNumber := left.(float64)
+	rightNumber, rightIsNumber := right.(float64)
+
+	if operator == "==" {
+		if left == nil || right == nil {
+			return left == nil && right == nil
+		}
+		if leftIsNumber && rightIsNumber {
+			return leftNumber == rightNumber
+		}
+		return formatQueryValue(left) == formatQueryValue(right)
+	}
+
+	if left == nil || right == nil {
+		return false
+	}
+
+	var order int
+	if leftIsNumber && rightIsNumber {
+		switch {
+		case leftNumber < rightNumber:
+			order = -1
+		case leftNumber > rightNumber:
+			order = 1
+		}
+	} else {
+		order = strings.Compare(formatQueryValue(left), formatQueryValue(right))
+	}
+
+	switch operator {
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	case ">=":
+		return order >= 0
+	}
+	return false
+}
+
+// queryTruthy reports whether a value counts as true in a boolean context
+func queryTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case float64:
+		return v != 0
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	default:
+		return true
+	}
+}
+
+// formatQueryValue formats a value for table and CSV output, using JSON for nested values
+func formatQueryValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(data)
+	}
+}
+
+// queryHeaders returns the table headers for the projected fields
+func queryHeaders(fields []string) []string {
+	headers := make([]string, len(fields))
+	for i, field := range fields {
+		headers[i] = strings.ToUpper(field)
+	}
+	return headers
+}
+
+// formatQueryRows formats query results as table cells
+func formatQueryRows(results [][]interface{}) [][]string {
+	rows := make([][]string, len(results))
+	for i, result := range results {
+		rows[i] = make([]string, len(result))
+		for j, value := range result {
+			rows[i][j] = formatQueryValue(value)
+		}
+	}
+	return rows
+}
+
+// formatQueryJSON formats query results as a JSON array of objects keyed by field
+func formatQueryJSON(fields []string, results [][]interface{}) (string, error) {
+	objects := make([]map[string]interface{}, len(results))
+	for i, result := range results {
+		objects[i] = make(map[string]interface{})
+		for j, field := range fields {
+			objects[i][field] = result[j]
+		}
+	}
+
+	data, err := json.MarshalIndent(objects, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encoding query results: %v", err)
+	}
+	return string(data) + "\n", nil
+}
+
+// formatQueryCSV formats query results as CSV with a header row
+func formatQueryCSV(fields []string, results [][]interface{}) (string, error) {
+	var content strings.Builder
+	writer := csv.NewWriter(&content)
+
+	if err := writer.Write(fields); err != nil {
+		return "", err
+	}
+	for _, row := range formatQueryRows(results) {
+		if err := writer.Write(row); err != nil {
+			return "", err
+		}
+	}
+	writer.Flush()
+
+	return content.String(), writer.Error()
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+// queryTestState is shaped like terraform show -json output: sensitive_values has an empty
+// map or list for every map and list attribute, and true for sensitive ones
+const queryTestState = `{
+  "format_version": "1.0",
+  "terraform_version": "1.9.0",
+  "values": {
+    "root_module": {
+      "resources": [
+        {
+          "address": "aws_instance.web",
+          "mode": "managed",
+          "type": "aws_instance",
+          "name": "web",
+          "provider_name": "registry.terraform.io/hashicorp/aws",
+          "schema_version": 1,
+          "values": {
+            "ami": "ami-0c55b159",
+            "instance_type": "t3.micro",
+            "tags": {"Owner": "alice", "CostCenter": "cc-42", "Environment": "prod"},
+            "root_block_device": [{"volume_size": 20, "volume_type": "gp3"}],
+            "user_data": "#!/bin/sh echo hunter2"
+          },
+          "sensitive_values": {"tags": {}, "root_block_device": [{}], "user_data": true},
+          "depends_on": ["aws_security_group.web"]
+        },
+        {
+          "address": "aws_security_group.web",
+          "mode": "managed",
+          "type": "aws_security_group",
+          "name": "web",
+          "provider_name": "registry.terraform.io/hashicorp/aws",
+          "values": {
+            "name": "web \"public\"",
+            "ingress": [{"from_port": 443, "cidr_blocks": ["0.0.0.0/0"]}, {"from_port": 22, "cidr_blocks": ["10.0.0.0/8"]}],
+            "tags": {}
+          },
+          "sensitive_values": {"ingress": [{"cidr_blocks": []}, {"cidr_blocks": []}], "tags": {}}
+        },
+        {
+          "address": "data.aws_region.current",
+          "mode": "data",
+          "type": "aws_region",
+          "name": "current",
+          "provider_name": "registry.terraform.io/hashicorp/aws",
+          "values": {"name": "us-east-1"},
+          "sensitive_values": {}
+        }
+      ],
+      "child_modules": [
+        {
+          "address": "module.db",
+          "resources": [
+            {
+              "address": "module.db.aws_db_instance.main",
+              "mode": "managed",
+              "type": "aws_db_instance",
+              "name": "main",
+              "provider_name": "registry.terraform.io/hashicorp/aws",
+              "values": {
+                "instance_class": "db.t3.micro",
+                "allocated_storage": 50,
+                "password": "correct-horse-battery",
+                "settings": {"engine": "postgres", "connection": "postgres://admin:pw@db"}
+              },
+              "sensitive_values": {"password": true, "settings": {"connection": true}}
+            }
+          ]
+        }
+      ]
+    }
+  }
+}`
+
+func loadQueryTestState(t *testing.T) *StateData {
+	t.Helper()
+	logOutput = io.Discard
+	if err := configureRedaction(redactionPartial, defaultSensitiveKeys, nil); err != nil {
+		t.Fatal(err)
+	}
+	stateData, err := parseStateJSON([]byte(queryTestState))
+	if err != nil {
+		t.Fatalf("parsing test state: %v", err)
+	}
+	return stateData
+}
+
+// queryRows runs a query and formats each result row as its cells joined by " | "
+func queryRows(t *testing.T, stateData *StateData, expression string, fields []string) []string {
+	t.Helper()
+	filter, err := parseQuery(expression)
+	if err != nil {
+		t.Fatalf("parseQuery(%q): %v", expression, err)
+	}
+	results, err := runQuery(stateData, filter, fields)
+	if err != nil {
+		t.Fatalf("runQuery(%q): %v", expression, err)
+	}
+	var rows []string
+	for _, row := range formatQueryRows(results) {
+		rows = append(rows, strings.Join(row, " | "))
+	}
+	return rows
+}
+
+func TestRunQuery(t *testing.T) {
+	stateData := loadQueryTestState(t)
+	address := []string{"address"}
+
+	tests := []struct {
+		expression string
+		fields     []string
+		want       []string
+	}{
+		{expression: "", fields: address, want: []string{"aws_instance.web", "aws_security_group.web", "data.aws_region.current", "module.db.aws_db_instance.main"}},
+		{expression: `type == "aws_instance"`, fields: address, want: []string{"aws_instance.web"}},
+		{expression: `mode != "managed"`, fields: address, want: []string{"data.aws_region.current"}},
+		{expression: `address =~ "^module\\."`, fields: address, want: []string{"module.db.aws_db_instance.main"}},
+		{expression: `address !~ "web"`, fields: address, want: []string{"data.aws_region.current", "module.db.aws_db_instance.main"}},
+		{expression: `values.allocated_storage >= 50`, fields: address, want: []string{"module.db.aws_db_instance.main"}},
+		{expression: `values.allocated_storage > 50`, fields: address, want: nil},
+		// && binds tighter than ||
+		{expression: `type == "aws_region" || type == "aws_instance" && values.instance_type == "t3.large"`, fields: address, want: []string{"data.aws_region.current"}},
+		{expression: `(type == "aws_region" || type == "aws_instance") && mode == "managed"`, fields: address, want: []string{"aws_instance.web"}},
+		{expression: `!(mode == "managed")`, fields: address, want: []string{"data.aws_region.current"}},
+		{expression: `!values.tags && mode == "managed"`, fields: address, want: []string{"aws_security_group.web", "module.db.aws_db_instance.main"}},
+		// A list matches when any element matches
+		{expression: `depends_on == "aws_security_group.web"`, fields: address, want: []string{"aws_instance.web"}},
+		{expression: `values.ingress[1].from_port == 22`, fields: address, want: []string{"aws_security_group.web"}},
+		{expression: `values.tags["CostCenter"] == 'cc-42'`, fields: address, want: []string{"aws_instance.web"}},
+		{expression: `values.name == "web \"public\""`, fields: address, want: []string{"aws_security_group.web"}},
+		{expression: `values.missing.deeper == null && type == "aws_region"`, fields: address, want: []string{"data.aws_region.current"}},
+		// Field projection
+		{expression: `type == "aws_instance"`, fields: []string{"name", "values.root_block_device[0].volume_size", "values.tags.Owner", "values.nothing"}, want: []string{"web | 20 | alice | "}},
+		{expression: `type == "aws_security_group"`, fields: []string{"values.ingress[0].cidr_blocks", "provider"}, want: []string{`["0.0.0.0/0"] | aws`}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			got := queryRows(t, stateData, test.expression, test.fields)
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("rows = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       string
+	}{
+		{expression: `type == "aws`, want: "unterminated string starting at position 8"},
+		{expression: `type # 1`, want: "unexpected character '#' at position 5"},
+		{expression: `(type == "a"`, want: "missing closing parenthesis"},
+		{expression: `values.tags["a"`, want: "missing closing bracket"},
+		{expression: `type ==`, want: "unexpected end of expression"},
+		{expression: `type == "a" name`, want: "unexpected 'name'"},
+		{expression: `address =~ type`, want: "right side of =~ must be a string pattern"},
+		{expression: `address =~ "("`, want: "invalid pattern '('"},
+		{expression: `values.`, want: "expected field name after '.'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			_, err := parseQuery(test.expression)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("parseQuery(%q) error = %v, want one containing %q", test.expression, err, test.want)
+			}
+		})
+	}
+}
+
+func TestRunQueryFieldErrors(t *testing.T) {
+	stateData := loadQueryTestState(t)
+	for _, field := range []string{`type == "a"`, `values.[`} {
+		if _, err := runQuery(stateData, nil, []string{field}); err == nil {
+			t.Errorf("runQuery accepted field %q", field)
+		}
+	}
+}
+
+func TestQueryMasking(t *testing.T) {
+	stateData := loadQueryTestState(t)
+
+	tests := []struct {
+		name       string
+		expression string
+		fields     []string
+		want       []string
+	}{
+		// "tags": {} in sensitive_values does not make the tags sensitive
+		{name: "empty map marker", expression: `values.tags.Owner == "alice"`, fields: []string{"values.tags"},
+			want: []string{`{"CostCenter":"cc-42","Environment":"prod","Owner":"alice"}`}},
+		{name: "empty list marker", expression: `values.root_block_device[0].volume_size == 20`, fields: []string{"values.root_block_device[0].volume_type"},
+			want: []string{"gp3"}},
+		{name: "true marker", expression: `type == "aws_instance"`, fields: []string{"values.user_data"},
+			want: []string{"#!/b...ter2"}},
+		{name: "nested true marker", expression: `type == "aws_db_instance"`, fields: []string{"values.settings"},
+			want: []string{`{"connection":"post...w@db","engine":"postgres"}`}},
+		{name: "projection of values", expression: `type == "aws_db_instance"`, fields: []string{"values"},
+			want: []string{`{"allocated_storage":50,"instance_class":"db.t3.micro","password":"corr...tery","settings":{"connection":"post...w@db","engine":"postgres"}}`}},
+		// Filters see masked values, so they cannot probe secrets
+		{name: "filter on secret", expression: `values.password == "correct-horse-battery"`, fields: []string{"address"}, want: nil},
+		{name: "regex on secret", expression: `values.settings.connection =~ "admin"`, fields: []string{"address"}, want: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := queryRows(t, stateData, test.expression, test.fields)
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("rows = %q, want %q", got, test.want)
+			}
+		})
+	}
+
+	if err := configureRedaction(redactionFull, defaultSensitiveKeys, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer configureRedaction(redactionPartial, defaultSensitiveKeys, nil)
+	if got := queryRows(t, stateData, `type == "aws_db_instance"`, []string{"values.password"}); len(got) != 1 || got[0] != "***" {
+		t.Errorf("full redaction rows = %q, want ***", got)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// isRawState reports whether decoded JSON is a raw state file (terraform.tfstate, as
+// stored by backends) rather than the output of terraform show -json
+func isRawState(stateMap map[string]interface{}) bool {
+	_, hasValues := stateMap["values"]
+	_, hasVersion := stateMap["version"].(float64)
+	_, hasResources := stateMap["resources"].([]interface{})
+	return !hasValues && hasVersion && hasResources
+}
+
+// rawStateValues converts the resources and outputs of a raw state file into the
+// values section of terraform show -json output so both share one parser
+func rawStateValues(stateMap map[string]interface{}) (map[string]interface{}, error) {
+	if version, _ := stateMap["version"].(float64); version < 4 {
+		return nil, fmt.Errorf("state file version %d is not supported (expected 4)", int(version))
+	}
+
+	modules := map[string][]interface{}{}
+	for _, resourceData := range stateMap["resources"].([]interface{}) {
+		resourceMap, ok := resourceData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		module, _ := resourceMap["module"].(string)
+		instances, _ := resourceMap["instances"].([]interface{})
+		for _, instanceData := range instances {
+			instance, ok := instanceData.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			modules[module] = append(modules[module], rawInstanceResource(module, resourceMap, instance))
+		}
+	}
+
+	outputs := map[string]interface{}{}
+	if outputsData, ok := stateMap["outputs"].(map[string]interface{}); ok {
+		outputs = outputsData
+	}
+
+	rootModule := map[string]interface{}{
+		"resources":     modules[""],
+		"child_modules": rawChildModules("", modules),
+	}
+	return map[string]interface{}{"outputs": outputs, "root_module": rootModule}, nil
+}
+
+// rawInstanceResource converts one resource instance of a raw state file into the
+// resource form used by terraform show -json
+func rawInstanceResource(module string, resourceMap, instance map[string]interface{}) map[string]interface{} {
+	mode, _ := resourceMap["mode"].(string)
+	resourceType, _ := resourceMap["type"].(string)
+	name, _ := resourceMap["name"].(string)
+	provider, _ := resourceMap["provider"].(string)
+
+	address := resourceType + "." + name
+	if mode == "data" {
+		address = "data." + address
+	}
+	if module != "" {
+		address = module + "." + address
+	}
+
+	resource := map[string]interface{}{
+		"mode":           mode,
+		"type":           resourceType,
+		"name":           name,
+		"provider_name":  parseProviderAddress(provider).registryAddress(),
+		"schema_version": instance["schema_version"],
+		"values":         instance["attributes"],
+		"depends_on":     instance["dependencies"],
+	}
+
+	switch index := instance["index_key"].(type) {
+	case string:
+		address += fmt.Sprintf("[%q]", index)
+		resource["index"] = index
+	case float64:
+		address += fmt.Sprintf("[%d]", int(index))
+		resource["index"] = index
+	}
+	resource["address"] = address
+
+	// Sensitive attributes are recorded as paths; the top-level attribute of each path is masked
+	sensitive := map[string]interface{}{}
+	paths, _ := instance["sensitive_attributes"].([]interface{})
+	for _, pathData := range paths {
+		if steps, ok := pathData.([]interface{}); ok && len(steps) > 0 {
+			if step, ok := steps[0].(map[string]interface{}); ok && step["type"] == "get_attr" {
+				sensitive[fmt.Sprint(step["value"])] = true
+			}
+		}
+	}
+	resource["sensitive_values"] = sensitive
+
+	return resource
+}
+
+// rawChildModules builds the child modules of parent from resources grouped by
+// module address, adding intermediate modules that hold no resources of their own
+func rawChildModules(parent string, modules map[string][]interface{}) []interface{} {
+	children := map[string]bool{}
+	for address := range modules {
+		for address != "" {
+			next := parentModuleAddress(address)
+			if next == parent {
+				children[address] = true
+				break
+			}
+			address = next
+		}
+	}
+
+	addresses := make([]string, 0, len(children))
+	for address := range children {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	var childModules []interface{}
+	for _, address := range addresses {
+		childModules = append(childModules, map[string]interface{}{
+			"address":       address,
+			"resources":     modules[address],
+			"child_modules": rawChildModules(address, modules),
+		})
+	}
+	return childModules
+}
+
+// parentModuleAddress returns the address of the module containing a module, e.g.
+// module.app["a.b"].module.db becomes module.app["a.b"]; the root module is ""
+func parentModuleAddress(address string) string {
+	depth, quoted := 0, false
+	last := -1
+	for i := 0; i < len(address); i++ {
+		switch c := address[i]; {
+		case c == '"' && (i == 0 || address[i-1] != '\\'):
+			quoted = !quoted
+		case quoted:
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case depth == 0 && strings.HasPrefix(address[i:], ".module."):
+			last = i
+		}
+	}
+	if last < 0 {
+		return ""
+	}
+	return address[:last]
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// emptyPayloadHash is the SHA-256 of an empty request body
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Location identifies a state object in S3 or an S3-compatible store, following the
+// layout of Terraform's S3 backend: the default workspace is stored at Key and other
+// workspaces at <WorkspacePrefix>/<workspace>/<Key>
+type S3Location struct {
+	Bucket          string
+	Key             string
+	Region          string
+	Endpoint        string
+	Workspace       string
+	WorkspacePrefix string
+}
+
+// S3Client reads objects with requests signed by AWS Signature Version 4
+type S3Client struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	Client       *http.Client
+}
+
+// parseS3Input parses s3://bucket/key[?region=..&endpoint=..&workspace=..&workspace_key_prefix=..].
+// Region and endpoint default to AWS_REGION (or AWS_DEFAULT_REGION) and AWS_ENDPOINT_URL_S3
+// (or AWS_ENDPOINT_URL).
+func parseS3Input(input string) (*S3Location, error) {
+	parsed, err := url.Parse(input)
+	if err != nil || parsed.Scheme != "s3" || parsed.Host == "" || strings.Trim(parsed.Path, "/") == "" {
+		return nil, fmt.Errorf("invalid S3 location '%s' (expected s3://bucket/key)", input)
+	}
+
+	query := parsed.Query()
+	location := &S3Location{
+		Bucket:          parsed.Host,
+		Key:             strings.TrimPrefix(parsed.Path, "/"),
+		Region:          firstNonEmpty(query.Get("region"), os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), "us-east-1"),
+		Endpoint:        strings.TrimSuffix(firstNonEmpty(query.Get("endpoint"), os.Getenv("AWS_ENDPOINT_URL_S3"), os.Getenv("AWS_ENDPOINT_URL")), "/"),
+		Workspace:       firstNonEmpty(query.Get("workspace"), "default"),
+		WorkspacePrefix: firstNonEmpty(query.Get("workspace_key_prefix"), "env:"),
+	}
+	return location, nil
+}
+
+// firstNonEmpty returns the first value that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// objectKey returns the key of the state object for the selected workspace
+func (l *S3Location) objectKey() string {
+	if l.Workspace == "" || l.Workspace == "default" {
+		return l.Key
+	}
+	return l.WorkspacePrefix + "/" + l.Workspace + "/" + l.Key
+}
+
+// String returns the location without query parameters, for messages
+func (l *S3Location) String() string {
+	return "s3://" + l.Bucket + "/" + l.objectKey()
+}
+
+// newS3Client creates a client from the standard AWS credential environment variables;
+// without them requests are sent unsigned, which works for public buckets
+func newS3Client() *S3Client {
+	return &S3Client{
+		AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		Client:       &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+// objectURL returns the URL of an object, or of the bucket when key is empty. Custom
+// endpoints such as MinIO use path-style addressing, AWS uses virtual-hosted style.
+func (l *S3Location) objectURL(key string, query url.Values) string {
+	path := "/" + s3EscapePath(key)
+	var base string
+	if l.Endpoint != "" {
+		base = l.Endpoint + "/" + l.Bucket
+	} else {
+		base = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", l.Bucket, l.Region)
+	}
+	if encoded := s3EncodeQuery(query); encoded != "" {
+		return base + path + "?" + encoded
+	}
+	return base + path
+}
+
+// get sends a signed GET request and returns the response body
+func (c *S3Client) get(location *S3Location, key string, query url.Values) ([]byte, int, error) {
+	request, err := http.NewRequest(http.MethodGet, location.objectURL(key, query), nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid S3 request: %v", err)
+	}
+	c.sign(request, location.Region, time.Now().UTC())
+
+	response, err := c.Client.Do(request)
+	if err != nil {
+		return nil, 0, fmt.Errorf("requesting %s: %v", request.URL.Redacted(), err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("reading %s: %v", request.URL.Redacted(), err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return body, response.StatusCode, s3Error(response.Status, body)
+	}
+	return body, response.StatusCode, nil
+}
+
+// fetchState downloads the state object of the selected workspace
+func (c *S3Client) fetchState(location *S3Location) ([]byte, error) {
+	body, status, err := c.get(location, location.objectKey(), nil)
+	var s3Err *S3Error
+	if status == http.StatusNotFound && errors.As(err, &s3Err) && (s3Err.Code == "" || s3Err.Code == "NoSuchKey") {
+		return nil, fmt.Errorf("no state stored at %s", location)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %v", location, err)
+	}
+
+	fmt.Fprintf(logOutput, "Fetched %d bytes of state from %s\n", len(body), location)
+	return body, nil
+}
+
+// listWorkspaces returns the workspaces with a state object, "default" first
+func (c *S3Client) listWorkspaces(location *S3Location) ([]string, error) {
+	var workspaces []string
+
+	keys, err := c.listKeys(location, location.Key)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if key == location.Key {
+			workspaces = append(workspaces, "default")
+		}
+	}
+
+	prefix := location.WorkspacePrefix + "/"
+	keys, err = c.listKeys(location, prefix)
+	if err != nil {
+		return nil, err
+	}
+	var named []string
+	for _, key := range keys {
+		// Keys look like env:/<workspace>/<key>
+		rest := strings.TrimPrefix(key, prefix)
+		workspace, stateKey, found := strings.Cut(rest, "/")
+		if found && workspace != "" && stateKey == location.Key {
+			named = append(named, workspace)
+		}
+	}
+	sort.Strings(named)
+
+	return append(workspaces, named...), nil
+}
+
+// listKeys lists the object keys under a prefix, following continuation tokens
+func (c *S3Client) listKeys(location *S3Location, prefix string) ([]string, error) {
+	var keys []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		body, _, err := c.get(location, "", query)
+		if err != nil {
+			return nil, fmt.Errorf("listing s3://%s/%s: %v", location.Bucket, prefix, err)
+		}
+
+		var result struct {
+			Contents []struct {
+				Key string `xml:"Key"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("parsing listing of s3://%s/%s: %v", location.Bucket, prefix, err)
+		}
+		for _, object := range result.Contents {
+			keys = append(keys, object.Key)
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return keys, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// S3Error is an error response from S3
+type S3Error struct {
+	Status  string `xml:"-"`
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (e *S3Error) Error() string {
+	if e.Code == "" {
+		return "unexpected status " + e.Status
+	}
+	return e.Code + ": " + e.Message
+}
+
+// s3Error builds an error from an S3 XML error response
+func s3Error(status string, body []byte) error {
+	response := &S3Error{}
+	// Bodies that are not XML leave the code empty
+	xml.Unmarshal(body, response)
+	response.Status = status
+	return response
+}
+
+// sign adds AWS Signature Version 4 headers to a request without a body
+func (c *S3Client) sign(request *http.Request, region string, now time.Time) {
+	if c.AccessKey == "" || c.SecretKey == "" {
+		return
+	}
+
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	request.Header.Set("x-amz-date", amzDate)
+	request.Header.Set("x-amz-content-sha256", emptyPayloadHash)
+	if c.SessionToken != "" {
+		request.Header.Set("x-amz-security-token", c.SessionToken)
+	}
+
+	headers := map[string]string{"host": request.URL.Host}
+	for name := range request.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(request.Header.Get(name))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		s3EncodeQuery(request.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	hashedRequest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hashedRequest[:])
+
+	key := []byte("AWS4" + c.SecretKey)
+	for _, part := range []string{date, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.AccessKey, scope, signedHeaders, signature))
+}
+
+// hmacSHA256 computes an HMAC-SHA256 of data with key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Escape percent-encodes a string as required by Signature Version 4, leaving only
+// unreserved characters (and "/" when escaping paths) as they are
+func s3Escape(value string, keepSlash bool) string {
+	var escaped strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (keepSlash && c == '/') {
+			escaped.WriteByte(c)
+		} else {
+			escaped.WriteString(fmt.Sprintf("%%%02X", c))
+		}
+	}
+	return escaped.String()
+}
+
+// s3EscapePath encodes an object key for use in a URL path
+func s3EscapePath(key string) string {
+	return s3Escape(key, true)
+}
+
+// s3EncodeQuery encodes query parameters sorted by name, as Signature Version 4 requires
+func s3EncodeQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var parts []string
+	for _, name := range names {
+		for _, value := range query[name] {
+			parts = append(parts, s3Escape(name, false)+"="+s3Escape(value, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 )
@@ -14,6 +15,14 @@ type StateData struct {
 	Outputs          []Output       `json:"-"`
 	ResourceCounts   map[string]int `json:"-"`
 	RootModule       RootModule     `json:"-"`
+	// Serial and Lineage are only recorded in raw state files; Serial is 0 otherwise
+	Serial  int    `json:"-"`
+	Lineage string `json:"-"`
+	// Tool is Terraform or OpenTofu; Encrypted is set for decrypted OpenTofu states
+	Tool      string `json:"-"`
+	Encrypted bool   `json:"-"`
+	// Filter is set when resources were filtered out before rendering
+	Filter *FilterSummary `json:"-"`
 }
 
 // StateValues represents the values section of the state
@@ -50,11 +59,17 @@ type Resource struct {
 	Mode            string                 `json:"mode"`
 	Type            string                 `json:"type"`
 	Name            string                 `json:"name"`
+	Index           interface{}            `json:"index,omitempty"`
 	ProviderName    string                 `json:"provider_name"`
 	SchemaVersion   int                    `json:"schema_version"`
 	Values          map[string]interface{} `json:"values"`
 	SensitiveValues map[string]interface{} `json:"sensitive_values"`
 	DependsOn       []string               `json:"depends_on"`
+	ModuleAddress   string                 `json:"-"`
+	Region          string                 `json:"-"`
+	Account         string                 `json:"-"`
+	// Schema is set when a provider schema file describes the resource type
+	Schema *ResourceSchema `json:"-"`
 }
 
 // Output represents a parsed output
@@ -72,6 +87,25 @@ func parseStateData(rawData interface{}) (*StateData, error) {
 		return nil, fmt.Errorf("invalid state data format")
 	}
 
+	// OpenTofu can encrypt the whole state; decrypt it and parse the plaintext
+	if isEncryptedState(stateMap) {
+		plaintext, err := decryptOpenTofuState(stateMap)
+		if err != nil {
+			return nil, err
+		}
+		var decrypted interface{}
+		if err := json.Unmarshal(plaintext, &decrypted); err != nil {
+			return nil, fmt.Errorf("parsing decrypted state: %v", err)
+		}
+		state, err := parseStateData(decrypted)
+		if err != nil {
+			return nil, err
+		}
+		state.Tool = toolOpenTofu
+		state.Encrypted = true
+		return state, nil
+	}
+
 	state := &StateData{
 		ResourceCounts: make(map[string]int),
 	}
@@ -86,6 +120,24 @@ func parseStateData(rawData interface{}) (*StateData, error) {
 		state.TerraformVersion = terraformVersion
 	}
 
+	// Raw state files record serial and lineage, and their resources need converting
+	if isRawState(stateMap) {
+		if serial, ok := stateMap["serial"].(float64); ok {
+			state.Serial = int(serial)
+		}
+		if lineage, ok := stateMap["lineage"].(string); ok {
+			state.Lineage = lineage
+		}
+		if state.FormatVersion == "" {
+			state.FormatVersion = fmt.Sprint(stateMap["version"])
+		}
+		valuesData, err := rawStateValues(stateMap)
+		if err != nil {
+			return nil, err
+		}
+		stateMap["values"] = valuesData
+	}
+
 	// Parse values section
 	if valuesData, ok := stateMap["values"].(map[string]interface{}); ok {
 		if err := parseValues(valuesData, state); err != nil {
@@ -93,6 +145,11 @@ func parseStateData(rawData interface{}) (*StateData, error) {
 		}
 	}
 
+	// Derive the region and account of each resource
+	assignResourceLocations(state)
+
+	state.Tool = detectStateTool(state)
+
 	return state, nil
 }
 
@@ -139,49 +196,10 @@ func parseRootModule(rootModuleData map[string]interface{}, state *StateData) er
 	if resourcesData, ok := rootModuleData["resources"].([]interface{}); ok {
 		for _, resourceData := range resourcesData {
 			if resourceMap, ok := resourceData.(map[string]interface{}); ok {
-				resource := Resource{}
-
-				if address, ok := resourceMap["address"].(string); ok {
-					resource.Address = address
-				}
-
-				if mode, ok := resourceMap["mode"].(string); ok {
-					resource.Mode = mode
-				}
-
-				if resourceType, ok := resourceMap["type"].(string); ok {
-					resource.Type = resourceType
-				}
-
-				if name, ok := resourceMap["name"].(string); ok {
-					resource.Name = name
-				}
-
-				if providerName, ok := resourceMap["provider_name"].(string); ok {
-					resource.ProviderName = providerName
-				}
-
-				if schemaVersion, ok := resourceMap["schema_version"].(float64); ok {
-					resource.SchemaVersion = int(schemaVersion)
-				}
-
-				if values, ok := resourceMap["values"].(map[string]interface{}); ok {
-					resource.Values = values
-				}
-
-				if sensitiveValues, ok := resourceMap["sensitive_values"].(map[string]interface{}); ok {
-					resource.SensitiveValues = sensitiveValues
-				}
-
-				if dependsOn, ok := resourceMap["depends_on"].([]interface{}); ok {
-					for _, dep := range dependsOn {
-						if depStr, ok := dep.(string); ok {
-							resource.DependsOn = append(resource.DependsOn, depStr)
-						}
-					}
-				}
+				resource := parseResource(resourceMap)
 
 				state.Resources = append(state.Resources, resource)
+				state.RootModule.Resources = append(state.RootModule.Resources, resource)
 
 				// Count resources by type
 				resourceTypeKey := resource.Type
@@ -222,6 +240,51 @@ func formatResourceMode(mode string) string {
 	}
 }
 
+// providerShortName returns the provider type from a provider address
+// (e.g. "registry.terraform.io/hashicorp/aws" becomes "aws")
+func providerShortName(providerName string) string {
+	if providerName == "" {
+		return "unknown"
+	}
+	return parseProviderAddress(providerName).Name
+}
+
+// Redaction modes for sensitive values
+const (
+	redactionPartial = "partial"
+	redactionFull    = "full"
+	redactionNone    = "none"
+)
+
+// defaultSensitiveKeys are the attribute name fragments treated as sensitive
+var defaultSensitiveKeys = []string{
+	"password", "secret", "key", "token", "credential",
+	"private_key", "public_key", "certificate", "ca_cert",
+	"access_key", "secret_key", "api_key", "auth_token",
+}
+
+// redaction controls how sensitive values are detected and masked; it is set
+// from the configuration file and flags
+var redaction = struct {
+	Mode             string
+	SensitiveKeys    []string
+	NonSensitiveKeys []string
+}{Mode: redactionPartial, SensitiveKeys: defaultSensitiveKeys}
+
+// configureRedaction sets the redaction mode and sensitive key heuristics
+func configureRedaction(mode string, sensitiveKeys, nonSensitiveKeys []string) error {
+	switch mode {
+	case redactionPartial, redactionFull, redactionNone:
+	default:
+		return fmt.Errorf("unsupported redaction mode '%s' (expected partial, full or none)", mode)
+	}
+
+	redaction.Mode = mode
+	redaction.SensitiveKeys = sensitiveKeys
+	redaction.NonSensitiveKeys = nonSensitiveKeys
+	return nil
+}
+
 // isSensitiveValue checks if a value should be masked as sensitive
 func isSensitiveValue(key string, value interface{}, sensitiveValues map[string]interface{}) bool {
 	// Check if the key is explicitly marked as sensitive
@@ -231,16 +294,16 @@ func isSensitiveValue(key string, value interface{}, sensitiveValues map[string]
 		}
 	}
 
-	// Check for common sensitive field names
-	sensitiveKeys := []string{
-		"password", "secret", "key", "token", "credential",
-		"private_key", "public_key", "certificate", "ca_cert",
-		"access_key", "secret_key", "api_key", "auth_token",
+	keyLower := strings.ToLower(key)
+	for _, allowedKey := range redaction.NonSensitiveKeys {
+		if keyLower == strings.ToLower(allowedKey) {
+			return false
+		}
 	}
 
-	keyLower := strings.ToLower(key)
-	for _, sensitiveKey := range sensitiveKeys {
-		if strings.Contains(keyLower, sensitiveKey) {
+	// Check for common sensitive field names
+	for _, sensitiveKey := range redaction.SensitiveKeys {
+		if strings.Contains(keyLower, strings.ToLower(sensitiveKey)) {
 			return true
 		}
 	}
@@ -250,9 +313,13 @@ func isSensitiveValue(key string, value interface{}, sensitiveValues map[string]
 
 // maskSensitiveValue returns a masked version of a sensitive value
 func maskSensitiveValue(value interface{}) string {
+	if redaction.Mode == redactionNone {
+		return formatValue(value)
+	}
+
 	switch v := value.(type) {
 	case string:
-		if len(v) > 8 {
+		if len(v) > 8 && redaction.Mode == redactionPartial {
 			return v[:4] + "..." + v[len(v)-4:]
 		}
 		return "***"
@@ -265,6 +332,76 @@ func maskSensitiveValue(value interface{}) string {
 	}
 }
 
+// isSensitiveEntry checks if a value should be masked: its sensitive_values entry is true, or
+// its key looks like a secret. Entries that are maps or lists only mark values further down,
+// so their presence alone does not count.
+func isSensitiveEntry(key string, value interface{}, marker interface{}) bool {
+	if marked, ok := marker.(bool); ok && marked {
+		return true
+	}
+	return key != "" && isSensitiveValue(key, value, nil)
+}
+
+// sensitiveChild returns the sensitive_values entry for a map key or list index of a nested value
+func sensitiveChild(marker interface{}, key interface{}) interface{} {
+	switch m := marker.(type) {
+	case map[string]interface{}:
+		if name, ok := key.(string); ok {
+			return m[name]
+		}
+	case []interface{}:
+		if index, ok := key.(int); ok && index < len(m) {
+			return m[index]
+		}
+	}
+	return nil
+}
+
+// maskSensitiveTree returns a copy of a value with every sensitive map entry and list item
+// masked, following the nested sensitive_values structure given as marker
+func maskSensitiveTree(value interface{}, marker interface{}) interface{} {
+	if value == nil || redaction.Mode == redactionNone {
+		return value
+	}
+	if marked, ok := marker.(bool); ok && marked {
+		return maskSensitiveValue(value)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			child := sensitiveChild(marker, key)
+			if isSensitiveEntry(key, item, child) {
+				masked[key] = maskSensitiveValue(item)
+			} else {
+				masked[key] = maskSensitiveTree(item, child)
+			}
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i, item := range v {
+			masked[i] = maskSensitiveTree(item, sensitiveChild(marker, i))
+		}
+		return masked
+	default:
+		return value
+	}
+}
+
+// maskedResourceValue returns an attribute of a resource with sensitive data masked at every
+// level. terraform show -json writes an empty map or list to sensitive_values for every map
+// or list attribute, e.g. "tags": {}, so only a true marker masks the attribute as a whole.
+func maskedResourceValue(resource Resource, key string) interface{} {
+	value := resource.Values[key]
+	marker := resource.SensitiveValues[key]
+	if value != nil && redaction.Mode != redactionNone && isSensitiveEntry(key, value, marker) {
+		return maskSensitiveValue(value)
+	}
+	return maskSensitiveTree(value, marker)
+}
+
 // parseModules recursively parses child modules
 func parseModules(modulesData []interface{}, parentAddress string) ([]Module, error) {
 	var modules []Module
@@ -283,6 +420,7 @@ func parseModules(modulesData []interface{}, parentAddress string) ([]Module, er
 				for _, resourceData := range resourcesData {
 					if resourceMap, ok := resourceData.(map[string]interface{}); ok {
 						resource := parseResource(resourceMap)
+						resource.ModuleAddress = module.Address
 						module.Resources = append(module.Resources, resource)
 					}
 				}
@@ -348,6 +486,10 @@ func parseResource(resourceMap map[string]interface{}) Resource {
 		resource.Name = name
 	}
 
+	if index, ok := resourceMap["index"]; ok {
+		resource.Index = index
+	}
+
 	if providerName, ok := resourceMap["provider_name"].(string); ok {
 		resource.ProviderName = providerName
 	}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// isRemoteInput reports whether an input names a remote state location rather than a local file
+func isRemoteInput(input string) bool {
+	for _, scheme := range []string{"http://", "https://", "s3://", "tfc://"} {
+		if strings.HasPrefix(input, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// readStateInput reads state JSON from a local file or a remote state location; compressed
+// and archived state is unwrapped in both cases
+func readStateInput(input string) ([]byte, error) {
+	var data []byte
+	var err error
+	switch {
+	case strings.HasPrefix(input, "http://"), strings.HasPrefix(input, "https://"):
+		data, err = newHTTPBackend(input).fetchState()
+	case strings.HasPrefix(input, "s3://"):
+		location, parseErr := parseS3Input(input)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		data, err = newS3Client().fetchState(location)
+	case strings.HasPrefix(input, "tfc://"):
+		data, err = fetchTFCState(input)
+	default:
+		return readLocalInput(input)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return readStateStream(bytes.NewReader(data), displayInput(input), "")
+}
+
+// WorkspaceInput is the state input of one workspace
+type WorkspaceInput struct {
+	Workspace string
+	Input     string
+}
+
+// expandWorkspaces resolves an input selecting all workspaces (s3://bucket/key?workspace=*
+// or a local project directory) into one input per workspace; other inputs are returned as they are
+func expandWorkspaces(input string) ([]WorkspaceInput, error) {
+	if !isRemoteInput(input) {
+		if info, err := os.Stat(input); err == nil && info.IsDir() {
+			return discoverWorkspaces(input)
+		}
+	}
+	if !strings.HasPrefix(input, "s3://") {
+		return []WorkspaceInput{{Input: input}}, nil
+	}
+
+	location, err := parseS3Input(input)
+	if err != nil || location.Workspace != "*" {
+		return []WorkspaceInput{{Input: input}}, err
+	}
+
+	workspaces, err := newS3Client().listWorkspaces(location)
+	if err != nil {
+		return nil, err
+	}
+	if len(workspaces) == 0 {
+		return nil, fmt.Errorf("no workspace states found for s3://%s/%s", location.Bucket, location.Key)
+	}
+
+	parsed, _ := url.Parse(input)
+	var inputs []WorkspaceInput
+	for _, workspace := range workspaces {
+		query := parsed.Query()
+		query.Set("workspace", workspace)
+		parsed.RawQuery = query.Encode()
+		inputs = append(inputs, WorkspaceInput{Workspace: workspace, Input: parsed.String()})
+	}
+	return inputs, nil
+}
+
+// discoverWorkspaces finds the state files of a project directory using the local backend
+// layout: terraform.tfstate for the default workspace, terraform.tfstate.d/<workspace>/terraform.tfstate
+// for the others, and the *.tfstate.backup files next to them. Backups are named after their
+// workspace, e.g. default.backup.
+func discoverWorkspaces(dir string) ([]WorkspaceInput, error) {
+	workspaceDirs, err := filepath.Glob(filepath.Join(dir, "terraform.tfstate.d", "*"))
+	if err != nil {
+		return nil, fmt.Errorf("listing workspaces in %s: %v", dir, err)
+	}
+	sort.Strings(workspaceDirs)
+
+	var inputs []WorkspaceInput
+	for _, workspaceDir := range append([]string{dir}, workspaceDirs...) {
+		workspace := "default"
+		if workspaceDir != dir {
+			workspace = filepath.Base(workspaceDir)
+		}
+
+		if isStateFile(filepath.Join(workspaceDir, "terraform.tfstate")) {
+			inputs = append(inputs, WorkspaceInput{Workspace: workspace, Input: filepath.Join(workspaceDir, "terraform.tfstate")})
+		}
+
+		backups, _ := filepath.Glob(filepath.Join(workspaceDir, "*.tfstate.backup"))
+		sort.Strings(backups)
+		for _, backup := range backups {
+			if !isStateFile(backup) {
+				continue
+			}
+			name := workspace + ".backup"
+			if base := strings.TrimSuffix(filepath.Base(backup), ".tfstate.backup"); base != "terraform" {
+				name = workspace + "-" + base + ".backup"
+			}
+			inputs = append(inputs, WorkspaceInput{Workspace: name, Input: backup})
+		}
+	}
+
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no state files found in %s (expected terraform.tfstate or terraform.tfstate.d/<workspace>/terraform.tfstate)", dir)
+	}
+	return inputs, nil
+}
+
+// isStateFile reports whether a path is a regular, non-empty file; workspaces that were
+// created but never applied can leave empty state files behind
+func isStateFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular() && info.Size() > 0
+}
+
+// displayInput returns the input for log messages with any credentials in it hidden
+func displayInput(input string) string {
+	if !isRemoteInput(input) {
+		return input
+	}
+	parsed, err := url.Parse(input)
+	if err != nil {
+		return input
+	}
+	return parsed.Redacted()
+}
+
+// workspaceOutputPath inserts the workspace name into an output path, e.g.
+// state.html becomes state-staging.html
+func workspaceOutputPath(outputFile, workspace string) string {
+	if outputFile == "-" {
+		return outputFile
+	}
+	extension := filepath.Ext(outputFile)
+	return strings.TrimSuffix(outputFile, extension) + "-" + workspace + extension
+}
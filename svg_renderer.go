@@ -0,0 +1,349 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+)
+
+// Layout constants for the SVG renderer, in pixels
+const (
+	svgNodeHeight     = 34
+	svgNodeMinWidth   = 120
+	svgCharWidth      = 7
+	svgNodePadding    = 24
+	svgColumnGap      = 60
+	svgRowGap         = 14
+	svgClusterPadding = 16
+	svgClusterTitle   = 24
+	svgClusterGap     = 20
+	svgMaxRowWidth    = 1400
+	svgMargin         = 20
+	svgHeaderHeight   = 40
+	svgLegendRow      = 20
+)
+
+// providerColors maps provider short names to their node colors
+var providerColors = map[string]string{
+	"aws":        "#ff9900",
+	"azurerm":    "#0078d4",
+	"azuread":    "#0078d4",
+	"google":     "#4285f4",
+	"kubernetes": "#326ce5",
+	"helm":       "#0f1689",
+	"random":     "#7f8c8d",
+	"null":       "#95a5a6",
+	"tls":        "#16a085",
+	"local":      "#95a5a6",
+}
+
+// fallbackProviderColors are assigned to providers without a predefined color
+var fallbackProviderColors = []string{"#27ae60", "#c0392b", "#8e44ad", "#d35400", "#2c3e50", "#16a085"}
+
+// svgBox is a laid out rectangle with coordinates relative to its parent
+type svgBox struct {
+	X, Y, Width, Height int
+}
+
+// svgClusterLayout holds the laid out contents of a cluster
+type svgClusterLayout struct {
+	Cluster  *GraphCluster
+	Box      svgBox
+	Nodes    map[string]svgBox
+	Children []*svgClusterLayout
+}
+
+// generateSvg renders the resource graph as a standalone SVG document
+func generateSvg(graph *ResourceGraph, title string) string {
+	nodes := make(map[string]GraphNode)
+	for _, node := range graph.Nodes {
+		nodes[node.ID] = node
+	}
+
+	ranks := rankGraphNodes(graph)
+	colors := assignProviderColors(graph.Nodes)
+
+	// Lay out the cluster tree, then flatten it into absolute coordinates
+	root := layoutSvgCluster(graph.Root, nodes, ranks, true)
+	root.Box.X = svgMargin
+	root.Box.Y = svgMargin + svgHeaderHeight
+
+	positions := make(map[string]svgBox)
+	var boxes []*svgClusterLayout
+	resolveSvgPositions(root, 0, 0, positions, &boxes)
+
+	var providers []string
+	for provider := range colors {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+
+	width := root.Box.Width + 2*svgMargin
+	if width < 400 {
+		width = 400
+	}
+	legendHeight := svgLegendRow * (len(providers) + 1)
+	height := root.Box.Y + root.Box.Height + svgMargin + legendHeight
+
+	var svg strings.Builder
+	svg.WriteString(fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Arial, sans-serif">
+  <defs>
+    <marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse">
+      <path d="M 0 0 L 10 5 L 0 10 z" fill="#7f8c8d"/>
+    </marker>
+  </defs>
+  <rect width="100%%" height="100%%" fill="#ffffff"/>
+  <text x="%d" y="%d" font-size="20" font-weight="bold" fill="#2c3e50">%s</text>
+`, width, height, width, height, svgMargin, svgMargin+20, html.EscapeString(title)))
+
+	// Module boundary boxes, outermost first so nested boxes are drawn on top
+	for _, box := range boxes {
+		if box.Cluster.Address == "" {
+			continue
+		}
+		svg.WriteString(fmt.Sprintf(`  <g class="module">
+    <rect x="%d" y="%d" width="%d" height="%d" rx="6" fill="#9b59b6" fill-opacity="0.05" stroke="#9b59b6" stroke-dasharray="4 2"/>
+    <text x="%d" y="%d" font-size="12" font-weight="bold" fill="#8e44ad">%s</text>
+  </g>
+`, box.Box.X, box.Box.Y, box.Box.Width, box.Box.Height, box.Box.X+8, box.Box.Y+16, html.EscapeString(box.Cluster.Address)))
+	}
+
+	// Edges are drawn before nodes so that nodes cover the line ends
+	for _, edge := range graph.Edges {
+		from, fromOK := positions[edge.From]
+		to, toOK := positions[edge.To]
+		if !fromOK || !toOK {
+			continue
+		}
+		svg.WriteString("  " + svgEdgePath(from, to, edge.Kind) + "\n")
+	}
+
+	for _, graphNode := range graph.Nodes {
+		box, ok := positions[graphNode.ID]
+		if !ok {
+			continue
+		}
+		color := "#9b59b6"
+		if graphNode.Mode != "module" {
+			color = colors[providerShortName(graphNode.ProviderName)]
+		}
+		dash := ""
+		if graphNode.Mode == "data" {
+			dash = ` stroke-dasharray="5 3"`
+		}
+		svg.WriteString(fmt.Sprintf(`  <g class="node">
+    <title>%s</title>
+    <rect x="%d" y="%d" width="%d" height="%d" rx="4" fill="#ffffff" stroke="%s" stroke-width="1.5"%s/>
+    <rect x="%d" y="%d" width="5" height="%d" fill="%s"/>
+    <text x="%d" y="%d" font-size="12" fill="#2c3e50">%s</text>
+  </g>
+`, html.EscapeString(graphNode.ID),
+			box.X, box.Y, box.Width, box.Height, color, dash,
+			box.X, box.Y, box.Height, color,
+			box.X+12, box.Y+box.Height/2+4, html.EscapeString(graphNodeLabel(graphNode))))
+	}
+
+	// Provider legend
+	legendY := root.Box.Y + root.Box.Height + svgMargin
+	svg.WriteString(fmt.Sprintf(`  <text x="%d" y="%d" font-size="12" font-weight="bold" fill="#2c3e50">Providers</text>
+`, svgMargin, legendY+12))
+	for i, provider := range providers {
+		y := legendY + svgLegendRow*(i+1)
+		svg.WriteString(fmt.Sprintf(`  <rect x="%d" y="%d" width="12" height="12" fill="%s"/>
+  <text x="%d" y="%d" font-size="12" fill="#2c3e50">%s</text>
+`, svgMargin, y, colors[provider], svgMargin+18, y+10, html.EscapeString(provider)))
+	}
+
+	svg.WriteString("</svg>\n")
+	return svg.String()
+}
+
+// layoutSvgCluster computes the size of a cluster and the relative positions of its contents
+func layoutSvgCluster(cluster *GraphCluster, nodes map[string]GraphNode, ranks map[string]int, isRoot bool) *svgClusterLayout {
+	layout := &svgClusterLayout{Cluster: cluster, Nodes: make(map[string]svgBox)}
+
+	padding, top := svgClusterPadding, svgClusterPadding+svgClusterTitle
+	if isRoot {
+		padding, top = 0, 0
+	}
+
+	// Group the cluster's own nodes into columns by rank
+	columns := make(map[int][]string)
+	var columnRanks []int
+	for _, nodeID := range cluster.Nodes {
+		rank := ranks[nodeID]
+		if _, exists := columns[rank]; !exists {
+			columnRanks = append(columnRanks, rank)
+		}
+		columns[rank] = append(columns[rank], nodeID)
+	}
+	sort.Ints(columnRanks)
+
+	x := padding
+	contentWidth, contentHeight := 0, 0
+	for _, rank := range columnRanks {
+		columnWidth := 0
+		for _, nodeID := range columns[rank] {
+			if w := svgNodeWidth(nodes[nodeID]); w > columnWidth {
+				columnWidth = w
+			}
+		}
+
+		y := top
+		for _, nodeID := range columns[rank] {
+			layout.Nodes[nodeID] = svgBox{X: x, Y: y, Width: columnWidth, Height: svgNodeHeight}
+			y += svgNodeHeight + svgRowGap
+		}
+
+		if y-svgRowGap-top > contentHeight {
+			contentHeight = y - svgRowGap - top
+		}
+		x += columnWidth + svgColumnGap
+		contentWidth = x - svgColumnGap - padding
+	}
+
+	// Place child clusters below the nodes, wrapping rows at the maximum width
+	rowX, rowY, rowHeight := padding, top, 0
+	if contentHeight > 0 {
+		rowY = top + contentHeight + svgClusterGap
+	}
+	for _, child := range cluster.Children {
+		childLayout := layoutSvgCluster(child, nodes, ranks, false)
+		if rowX > padding && rowX+childLayout.Box.Width > svgMaxRowWidth {
+			rowX = padding
+			rowY += rowHeight + svgClusterGap
+			rowHeight = 0
+		}
+		childLayout.Box.X = rowX
+		childLayout.Box.Y = rowY
+		layout.Children = append(layout.Children, childLayout)
+
+		rowX += childLayout.Box.Width + svgClusterGap
+		if childLayout.Box.Height > rowHeight {
+			rowHeight = childLayout.Box.Height
+		}
+		if rowX-svgClusterGap-padding > contentWidth {
+			contentWidth = rowX - svgClusterGap - padding
+		}
+		if rowY+rowHeight-top > contentHeight {
+			contentHeight = rowY + rowHeight - top
+		}
+	}
+
+	// Make sure the module title always fits in its box
+	if titleWidth := len(cluster.Address)*svgCharWidth + 16; !isRoot && titleWidth > contentWidth {
+		contentWidth = titleWidth
+	}
+
+	layout.Box.Width = contentWidth + 2*padding
+	layout.Box.Height = top + contentHeight + padding
+	return layout
+}
+
+// resolveSvgPositions converts relative cluster positions into absolute coordinates
+func resolveSvgPositions(layout *svgClusterLayout, offsetX, offsetY int, positions map[string]svgBox, boxes *[]*svgClusterLayout) {
+	layout.Box.X += offsetX
+	layout.Box.Y += offsetY
+	*boxes = append(*boxes, layout)
+
+	for nodeID, box := range layout.Nodes {
+		box.X += layout.Box.X
+		box.Y += layout.Box.Y
+		positions[nodeID] = box
+	}
+
+	for _, child := range layout.Children {
+		resolveSvgPositions(child, layout.Box.X, layout.Box.Y, positions, boxes)
+	}
+}
+
+// rankGraphNodes assigns each node a column based on the longest dependency chain leading to it
+func rankGraphNodes(graph *ResourceGraph) map[string]int {
+	ranks := make(map[string]int)
+	for _, node := range graph.Nodes {
+		ranks[node.ID] = 0
+	}
+
+	// Relax edges at most once per node so that dependency cycles terminate
+	for i := 0; i < len(graph.Nodes); i++ {
+		changed := false
+		for _, edge := range graph.Edges {
+			if ranks[edge.To] < ranks[edge.From]+1 {
+				ranks[edge.To] = ranks[edge.From] + 1
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	return ranks
+}
+
+// assignProviderColors picks a color for every provider used in the graph
+func assignProviderColors(nodes []GraphNode) map[string]string {
+	colors := make(map[string]string)
+	var unknown []string
+
+	for _, node := range nodes {
+		if node.Mode == "module" {
+			continue
+		}
+		provider := providerShortName(node.ProviderName)
+		if _, exists := colors[provider]; exists {
+			continue
+		}
+		if color, ok := providerColors[provider]; ok {
+			colors[provider] = color
+		} else {
+			colors[provider] = ""
+			unknown = append(unknown, provider)
+		}
+	}
+
+	sort.Strings(unknown)
+	for i, provider := range unknown {
+		colors[provider] = fallbackProviderColors[i%len(fallbackProviderColors)]
+	}
+
+	return colors
+}
+
+// svgNodeWidth estimates the width needed to display a node label
+func svgNodeWidth(node GraphNode) int {
+	width := len(graphNodeLabel(node))*svgCharWidth + svgNodePadding
+	if width < svgNodeMinWidth {
+		return svgNodeMinWidth
+	}
+	return width
+}
+
+// svgEdgePath returns an SVG path connecting two node boxes
+func svgEdgePath(from, to svgBox, kind string) string {
+	x1, y1 := from.X+from.Width, from.Y+from.Height/2
+	x2, y2 := to.X, to.Y+to.Height/2
+
+	// Targets to the left of the source are connected from the opposite sides
+	if x2 < x1 {
+		x1, x2 = from.X, to.X+to.Width
+	}
+
+	bend := (x2 - x1) / 2
+	if bend > -40 && bend < 40 {
+		bend = 40
+		if x2 < x1 {
+			bend = -40
+		}
+	}
+
+	style := ""
+	if kind == edgeKindContains {
+		style = ` stroke-dasharray="4 3"`
+	}
+
+	return fmt.Sprintf(`<path d="M %d %d C %d %d, %d %d, %d %d" fill="none" stroke="#7f8c8d" stroke-width="1.2"%s marker-end="url(#arrow)"/>`,
+		x1, y1, x1+bend, y1, x2-bend, y2, x2, y2, style)
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// tagAttributeNames are the resource attributes that hold tags or labels
+var tagAttributeNames = []string{"tags_all", "tags", "labels"}
+
+// TagPolicy describes the tags every taggable resource must carry
+type TagPolicy struct {
+	Required []string            `json:"required"`
+	Allowed  map[string][]string `json:"allowed,omitempty"`
+}
+
+// TagViolation describes a resource that does not satisfy the tag policy
+type TagViolation struct {
+	Address string            `json:"address"`
+	Module  string            `json:"module"`
+	Type    string            `json:"type"`
+	Missing []string          `json:"missing,omitempty"`
+	Invalid map[string]string `json:"invalid,omitempty"`
+}
+
+// TagComplianceGroup summarizes compliance for a module or resource type
+type TagComplianceGroup struct {
+	Name      string `json:"name"`
+	Taggable  int    `json:"taggable"`
+	Compliant int    `json:"compliant"`
+}
+
+// TagComplianceReport is the result of checking a state against a tag policy
+type TagComplianceReport struct {
+	Policy     TagPolicy            `json:"policy"`
+	Taggable   int                  `json:"taggable"`
+	Compliant  int                  `json:"compliant"`
+	Violations []TagViolation       `json:"violations"`
+	ByModule   []TagComplianceGroup `json:"by_module"`
+	ByType     []TagComplianceGroup `json:"by_type"`
+}
+
+// parseAllowedTagValues parses "Key=v1|v2,Other=v3" into allowed values per tag key
+func parseAllowedTagValues(value string) (map[string][]string, error) {
+	allowed := make(map[string][]string)
+
+	for _, entry := range splitList(value) {
+		key, values, ok := strings.Cut(entry, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid allowed tag values '%s' (expected Key=value1|value2)", entry)
+		}
+		for _, v := range strings.Split(values, "|") {
+			if v = strings.TrimSpace(v); v != "" {
+				allowed[strings.TrimSpace(key)] = append(allowed[strings.TrimSpace(key)], v)
+			}
+		}
+	}
+
+	return allowed, nil
+}
+
+// resourceTags returns the merged tags and labels of a resource and whether it is taggable
+func resourceTags(resource Resource) (map[string]string, bool) {
+	tags := make(map[string]string)
+	taggable := false
+
+	for _, attribute := range tagAttributeNames {
+		value, exists := resource.Values[attribute]
+		if !exists {
+			continue
+		}
+		taggable = true
+
+		tagMap, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key, tagValue := range tagMap {
+			if _, set := tags[key]; !set {
+				tags[key] = formatQueryValue(tagValue)
+			}
+		}
+	}
+
+	return tags, taggable
+}
+
+// lookupTag finds a tag by key; GCP labels are lowercase so keys also match case-insensitively
+func lookupTag(tags map[string]string, key string) (string, bool) {
+	if value, ok := tags[key]; ok {
+		return value, true
+	}
+	for tagKey, value := range tags {
+		if strings.EqualFold(tagKey, key) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// evaluateTagCompliance checks every taggable managed resource against the tag policy
+func evaluateTagCompliance(stateData *StateData, policy TagPolicy) *TagComplianceReport {
+	report := &TagComplianceReport{Policy: policy}
+	byModule := make(map[string]*TagComplianceGroup)
+	byType := make(map[string]*TagComplianceGroup)
+
+	for _, resource := range stateData.Resources {
+		if resource.Mode == "data" {
+			continue
+		}
+		tags, taggable := resourceTags(resource)
+		if !taggable {
+			continue
+		}
+
+		violation := TagViolation{
+			Address: resource.Address,
+			Module:  moduleDisplayName(resource.ModuleAddress),
+			Type:    resource.Type,
+		}
+		for _, key := range policy.Required {
+			value, ok := lookupTag(tags, key)
+			if !ok || value == "" {
+				violation.Missing = append(violation.Missing, key)
+			}
+		}
+		for key, allowedValues := range policy.Allowed {
+			value, ok := lookupTag(tags, key)
+			if !ok || containsString(allowedValues, value) {
+				continue
+			}
+			if violation.Invalid == nil {
+				violation.Invalid = make(map[string]string)
+			}
+			violation.Invalid[key] = value
+		}
+
+		compliant := len(violation.Missing) == 0 && len(violation.Invalid) == 0
+		report.Taggable++
+		if compliant {
+			report.Compliant++
+		} else {
+			report.Violations = append(report.Violations, violation)
+		}
+
+		addToComplianceGroup(byModule, violation.Module, compliant)
+		addToComplianceGroup(byType, resource.Type, compliant)
+	}
+
+	report.ByModule = sortedComplianceGroups(byModule)
+	report.ByType = sortedComplianceGroups(byType)
+	return report
+}
+
+// addToComplianceGroup counts a taggable resource in its group
+func addToComplianceGroup(groups map[string]*TagComplianceGroup, name string, compliant bool) {
+	group, exists := groups[name]
+	if !exists {
+		group = &TagComplianceGroup{Name: name}
+		groups[name] = group
+	}
+	group.Taggable++
+	if compliant {
+		group.Compliant++
+	}
+}
+
+// sortedComplianceGroups returns the groups ordered by name
+func sortedComplianceGroups(groups map[string]*TagComplianceGroup) []TagComplianceGroup {
+	sorted := make([]TagComplianceGroup, 0, len(groups))
+	for _, group := range groups {
+		sorted = append(sorted, *group)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// containsString reports whether a slice contains a string
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// compliancePercent returns the compliant share as a percentage
+func compliancePercent(compliant, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(compliant) * 100 / float64(total)
+}
+
+// formatViolationProblems describes the missing and invalid tags of a violation
+func formatViolationProblems(violation TagViolation) string {
+	var problems []string
+	if len(violation.Missing) > 0 {
+		problems = append(problems, "missing "+strings.Join(violation.Missing, ", "))
+	}
+
+	var invalidKeys []string
+	for key := range violation.Invalid {
+		invalidKeys = append(invalidKeys, key)
+	}
+	sort.Strings(invalidKeys)
+	for _, key := range invalidKeys {
+		problems = append(problems, fmt.Sprintf("%s=%q not allowed", key, violation.Invalid[key]))
+	}
+
+	return strings.Join(problems, "; ")
+}
+
+// runTagsCommand runs the "tags" subcommand
+func runTagsCommand(args []string) error {
+	flags := flag.NewFlagSet("tags", flag.ExitOnError)
+	inputFile := flags.String("i", "", "Input file path (required)")
+	flags.String("required-tags", "", "Comma-separated tags every taggable resource must have")
+	flags.String("allowed-tag-values", "", "Allowed tag values, e.g. Environment=dev|stage|prod")
+	format := flags.String("format", "text", "Output format: text, json")
+	configPath := defineSubcommandFlags(flags)
+	flags.Parse(args)
+
+	if err := validateInput(*inputFile); err != nil {
+		return err
+	}
+
+	// -required-tags and -allowed-tag-values override required_tags and allowed_tag_values
+	options, err := loadSubcommandOptions(flags, *configPath)
+	if err != nil {
+		return err
+	}
+	policy := options.TagPolicy
+	if len(policy.Required) == 0 && len(policy.Allowed) == 0 {
+		return fmt.Errorf("a tag policy is required: set -required-tags, -allowed-tag-values or required_tags in the config file")
+	}
+
+	logOutput = os.Stderr
+	stateData, err := loadSubcommandState(*inputFile, options)
+	if err != nil {
+		return err
+	}
+
+	report := evaluateTagCompliance(stateData, policy)
+
+	switch *format {
+	case "json":
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding tag report: %v", err)
+		}
+		fmt.Println(string(data))
+	case "text":
+		fmt.Print(generateTagComplianceText(report, detectTerminal(os.Stdout)))
+	default:
+		return fmt.Errorf("unsupported tags output format '%s'", *format)
+	}
+
+	// A failing exit status lets CI pipelines gate on the policy
+	if len(report.Violations) > 0 {
+		return fmt.Errorf("%d resources violate the tag policy", len(report.Violations))
+	}
+	return nil
+}
+
+// generateTagComplianceText renders the tag compliance report for the terminal
+func generateTagComplianceText(report *TagComplianceReport, settings terminalSettings) string {
+	var text strings.Builder
+
+	text.WriteString(colorize("Tag Compliance", ansiBold, settings.Color) + "\n")
+	text.WriteString(fmt.Sprintf("Required tags: %s\n", strings.Join(report.Policy.Required, ", ")))
+	text.WriteString(fmt.Sprintf("%d of %d taggable resources compliant (%.1f%%)\n\n",
+		report.Compliant, report.Taggable, compliancePercent(report.Compliant, report.Taggable)))
+
+	for _, section := range []struct {
+		title  string
+		groups []TagComplianceGroup
+	}{{"MODULE", report.ByModule}, {"TYPE", report.ByType}} {
+		var rows [][]string
+		for _, group := range section.groups {
+			rows = append(rows, []string{group.Name, fmt.Sprintf("%d/%d", group.Compliant, group.Taggable),
+				fmt.Sprintf("%.1f%%", compliancePercent(group.Compliant, group.Taggable))})
+		}
+		text.WriteString(renderTable([]string{section.title, "COMPLIANT", "PERCENT"}, rows, settings) + "\n")
+	}
+
+	if len(report.Violations) > 0 {
+		var rows [][]string
+		for _, violation := range report.Violations {
+			rows = append(rows, []string{violation.Address, formatViolationProblems(violation)})
+		}
+		text.WriteString(renderTable([]string{"NON-COMPLIANT RESOURCE", "PROBLEMS"}, rows, settings))
+	}
+
+	return text.String()
+}
+
+// generateTagComplianceHtml renders the tag compliance section of the state overview
+func generateTagComplianceHtml(report *TagComplianceReport) string {
+	if report.Taggable == 0 {
+		return ""
+	}
+
+	var html strings.Builder
+	html.WriteString(fmt.Sprintf(`<div style="margin-top: 20px;">
+			<h3>Tag Compliance</h3>
+			<p class="section-description">Required tags: %s &mdash; %d of %d taggable resources compliant (%.1f%%)</p>`,
+		escapeHtml(strings.Join(report.Policy.Required, ", ")), report.Compliant, report.Taggable,
+		compliancePercent(report.Compliant, report.Taggable)))
+
+	for _, section := range []struct {
+		title  string
+		groups []TagComplianceGroup
+	}{{"By Module", report.ByModule}, {"By Type", report.ByType}} {
+		html.WriteString(`<h4>` + section.title + `</h4><div style="margin-top: 10px;">`)
+		for _, group := range section.groups {
+			color := "var(--ok)"
+			if group.Compliant < group.Taggable {
+				color = "var(--bad)"
+			}
+			html.WriteString(fmt.Sprintf(`
+				<div style="padding: 5px 0; border-bottom: 1px solid var(--border);">
+					<span style="font-weight: bold; color: var(--heading);">%s:</span>
+					<span style="color: %s; margin-left: 10px;">%d/%d compliant</span>
+				</div>`, escapeHtml(group.Name), color, group.Compliant, group.Taggable))
+		}
+		html.WriteString(`</div>`)
+	}
+
+	if len(report.Violations) > 0 {
+		html.WriteString(`<h4>Non-compliant Resources</h4><div style="margin-top: 10px;">`)
+		for _, violation := range report.Violations {
+			html.WriteString(fmt.Sprintf(`
+				<div class="attribute-item attribute-sensitive">
+					<span class="attribute-key">%s:</span>
+					<span class="attribute-value">%s</span>
+				</div>`, escapeHtml(violation.Address), escapeHtml(formatViolationProblems(violation))))
+		}
+		html.WriteString(`</div>`)
+	}
+
+	html.WriteString(`</div>`)
+	return html.String()
+}
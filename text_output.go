@@ -0,0 +1,291 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// ANSI escape sequences used by the terminal renderers
+const (
+	ansiReset   = "\033[0m"
+	ansiBold    = "\033[1m"
+	ansiDim     = "\033[2m"
+	ansiGreen   = "\033[32m"
+	ansiYellow  = "\033[33m"
+	ansiBlue    = "\033[34m"
+	ansiMagenta = "\033[35m"
+)
+
+// keyAttributeNames lists the resource values shown in the table's attributes column
+var keyAttributeNames = []string{"id", "name", "arn", "instance_type", "bucket", "cidr_block", "region", "location"}
+
+// terminalSettings describes how output should be rendered for the current terminal
+type terminalSettings struct {
+	Color bool
+	Width int
+}
+
+// detectTerminal returns the color and width settings for a file descriptor,
+// disabling color when it is not a TTY or NO_COLOR is set
+func detectTerminal(file *os.File) terminalSettings {
+	settings := terminalSettings{}
+
+	fd := int(file.Fd())
+	if !term.IsTerminal(fd) {
+		return settings
+	}
+
+	settings.Color = os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+	if width, _, err := term.GetSize(fd); err == nil && width > 0 {
+		settings.Width = width
+	}
+
+	return settings
+}
+
+// colorize wraps text in an ANSI color when color output is enabled
+func colorize(text, color string, enabled bool) string {
+	if !enabled || text == "" {
+		return text
+	}
+	return color + text + ansiReset
+}
+
+// generateTextTree renders the state as a tree of modules and resources
+func generateTextTree(stateData *StateData, settings terminalSettings) string {
+	var text strings.Builder
+
+	text.WriteString(colorize(stateData.Tool+" State", ansiBold, settings.Color))
+	text.WriteString(colorize(fmt.Sprintf(" (format %s, %s %s, %d resources, %d outputs)",
+		stateData.FormatVersion, strings.ToLower(stateData.Tool), stateData.TerraformVersion, len(stateData.Resources), len(stateData.Outputs)), ansiDim, settings.Color))
+	text.WriteString("\n")
+
+	text.WriteString(colorize("root", ansiMagenta+ansiBold, settings.Color) + "\n")
+	writeTextModule(&text, stateData.RootModule.Resources, stateData.RootModule.ChildModules, "", settings)
+
+	if len(stateData.Outputs) > 0 {
+		text.WriteString("\n" + colorize("outputs", ansiBlue+ansiBold, settings.Color) + "\n")
+
+		outputs := make([]Output, len(stateData.Outputs))
+		copy(outputs, stateData.Outputs)
+		sort.Slice(outputs, func(i, j int) bool { return outputs[i].Name < outputs[j].Name })
+
+		for i, output := range outputs {
+			branch := treeBranch(i == len(outputs)-1)
+			valueStr := formatValue(output.Value)
+			if output.Sensitive {
+				valueStr = colorize(maskSensitiveValue(output.Value)+" (sensitive)", ansiYellow, settings.Color)
+			}
+			text.WriteString(truncateLine(fmt.Sprintf("%s%s = %s", branch, output.Name, valueStr), settings.Width) + "\n")
+		}
+	}
+
+	return text.String()
+}
+
+// writeTextModule writes the resources and child modules of a module as tree branches
+func writeTextModule(text *strings.Builder, resources []Resource, childModules []Module, prefix string, settings terminalSettings) {
+	total := len(resources) + len(childModules)
+
+	for i, resource := range resources {
+		last := i == total-1
+		modeColor := ansiGreen
+		if resource.Mode == "data" {
+			modeColor = ansiYellow
+		}
+
+		name := strings.TrimPrefix(resource.Address, resource.ModuleAddress+".")
+		line := prefix + treeBranch(last) + colorize(name, modeColor, settings.Color) +
+			colorize(fmt.Sprintf("  %s", providerShortName(resource.ProviderName)), ansiDim, settings.Color)
+		text.WriteString(truncateLine(line, settings.Width) + "\n")
+	}
+
+	for i, module := range childModules {
+		last := len(resources)+i == total-1
+		line := prefix + treeBranch(last) + colorize(module.Address, ansiMagenta+ansiBold, settings.Color) +
+			colorize(fmt.Sprintf("  (%d resources)", countModuleResources(module)), ansiDim, settings.Color)
+		text.WriteString(truncateLine(line, settings.Width) + "\n")
+
+		childPrefix := prefix + "│   "
+		if last {
+			childPrefix = prefix + "    "
+		}
+		writeTextModule(text, module.Resources, module.ChildModules, childPrefix, settings)
+	}
+}
+
+// treeBranch returns the tree drawing characters for an entry
+func treeBranch(last bool) string {
+	if last {
+		return "└── "
+	}
+	return "├── "
+}
+
+// generateTextTable renders the resources as a table with aligned columns
+func generateTextTable(stateData *StateData, settings terminalSettings) string {
+	headers := []string{"ADDRESS", "TYPE", "PROVIDER", "MODE", "ATTRIBUTES"}
+	rows := make([][]string, 0, len(stateData.Resources))
+
+	for _, resource := range stateData.Resources {
+		rows = append(rows, []string{
+			resource.Address,
+			resource.Type,
+			providerShortName(resource.ProviderName),
+			formatResourceMode(resource.Mode),
+			formatKeyAttributes(resource),
+		})
+	}
+
+	return renderTable(headers, rows, settings)
+}
+
+// renderTable lays out rows under headers with aligned columns that fit the terminal width
+func renderTable(headers []string, rows [][]string, settings terminalSettings) string {
+	widths := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = utf8.RuneCountInString(header)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	// Shrink the widest columns until the table fits the terminal
+	if settings.Width > 0 {
+		shrinkColumns(widths, settings.Width-2*(len(widths)-1))
+	}
+
+	var text strings.Builder
+	writeTableRow(&text, headers, widths, ansiBold, settings.Color)
+	for _, row := range rows {
+		writeTableRow(&text, row, widths, "", settings.Color)
+	}
+
+	return text.String()
+}
+
+// writeTableRow writes a single table row padded to the column widths
+func writeTableRow(text *strings.Builder, cells []string, widths []int, color string, colorEnabled bool) {
+	for i, cell := range cells {
+		cell = truncateCell(cell, widths[i])
+		if i < len(cells)-1 {
+			cell += strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+2)
+		}
+		if color != "" {
+			cell = colorize(cell, color, colorEnabled)
+		}
+		text.WriteString(cell)
+	}
+	text.WriteString("\n")
+}
+
+// shrinkColumns reduces the widest columns until the total fits the available width
+func shrinkColumns(widths []int, available int) {
+	const minWidth = 8
+
+	for {
+		total := 0
+		widest := 0
+		for i, width := range widths {
+			total += width
+			if width > widths[widest] {
+				widest = i
+			}
+		}
+		if total <= available || widths[widest] <= minWidth {
+			return
+		}
+		widths[widest]--
+	}
+}
+
+// formatKeyAttributes returns the most useful resource values as key=value pairs
+func formatKeyAttributes(resource Resource) string {
+	var attributes []string
+
+	for _, key := range keyAttributeNames {
+		value, ok := resource.Values[key]
+		if !ok || value == nil {
+			continue
+		}
+
+		valueStr := formatValue(value)
+		if isSensitiveValue(key, value, resource.SensitiveValues) {
+			valueStr = maskSensitiveValue(value)
+		}
+		attributes = append(attributes, key+"="+valueStr)
+	}
+
+	return strings.Join(attributes, " ")
+}
+
+// truncateCell shortens a cell to the given width, marking truncation with an ellipsis
+func truncateCell(cell string, width int) string {
+	if utf8.RuneCountInString(cell) <= width {
+		return cell
+	}
+	runes := []rune(cell)
+	return string(runes[:width-1]) + "…"
+}
+
+// truncateLine shortens a line to the terminal width, keeping ANSI escape sequences intact
+func truncateLine(line string, width int) string {
+	if width <= 0 || visibleLength(line) <= width {
+		return line
+	}
+
+	var result strings.Builder
+	visible := 0
+	inEscape := false
+
+	for _, r := range line {
+		switch {
+		case r == '\033':
+			inEscape = true
+			result.WriteRune(r)
+		case inEscape:
+			if r == 'm' {
+				inEscape = false
+			}
+			result.WriteRune(r)
+		case visible < width-1:
+			visible++
+			result.WriteRune(r)
+		case visible == width-1:
+			visible++
+			result.WriteRune('…')
+		}
+	}
+
+	return result.String()
+}
+
+// visibleLength counts the characters of a line that are not part of ANSI escape sequences
+func visibleLength(line string) int {
+	count := 0
+	inEscape := false
+
+	for _, r := range line {
+		switch {
+		case r == '\033':
+			inEscape = true
+		case inEscape:
+			if r == 'm' {
+				inEscape = false
+			}
+		default:
+			count++
+		}
+	}
+
+	return count
+}
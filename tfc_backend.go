@@ -0,0 +1,326 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultTFCAddress is the Terraform Cloud API used unless another address is configured
+const defaultTFCAddress = "https://app.terraform.io"
+
+// TFCWorkspace identifies a Terraform Cloud or Terraform Enterprise workspace
+type TFCWorkspace struct {
+	Address      string
+	Organization string
+	Name         string
+	// Versions limits how many state versions the timeline reads
+	Versions int
+}
+
+// TFCClient reads state versions through the Terraform Cloud v2 API
+type TFCClient struct {
+	Address string
+	Token   string
+	Client  *http.Client
+}
+
+// TFCStateVersion is a state version of a workspace
+type TFCStateVersion struct {
+	ID          string
+	Serial      int
+	CreatedAt   time.Time
+	DownloadURL string
+}
+
+// tfcStateVersionData is a state version as returned by the API
+type tfcStateVersionData struct {
+	ID         string `json:"id"`
+	Attributes struct {
+		Serial      int       `json:"serial"`
+		CreatedAt   time.Time `json:"created-at"`
+		DownloadURL string    `json:"hosted-state-download-url"`
+	} `json:"attributes"`
+}
+
+// stateVersion converts the API representation
+func (d tfcStateVersionData) stateVersion() TFCStateVersion {
+	return TFCStateVersion{ID: d.ID, Serial: d.Attributes.Serial, CreatedAt: d.Attributes.CreatedAt, DownloadURL: d.Attributes.DownloadURL}
+}
+
+// parseTFCInput parses tfc://organization/workspace[?address=https://tfe.example.com&versions=N].
+// The address defaults to https://$TF_CLOUD_HOSTNAME, then to app.terraform.io.
+func parseTFCInput(input string) (*TFCWorkspace, error) {
+	parsed, err := url.Parse(input)
+	if err != nil || parsed.Scheme != "tfc" || parsed.Host == "" || strings.Trim(parsed.Path, "/") == "" || strings.Contains(strings.Trim(parsed.Path, "/"), "/") {
+		return nil, fmt.Errorf("invalid Terraform Cloud workspace '%s' (expected tfc://organization/workspace)", input)
+	}
+
+	query := parsed.Query()
+	address := query.Get("address")
+	if address == "" && os.Getenv("TF_CLOUD_HOSTNAME") != "" {
+		address = "https://" + os.Getenv("TF_CLOUD_HOSTNAME")
+	}
+
+	workspace := &TFCWorkspace{
+		Address:      strings.TrimSuffix(firstNonEmpty(address, defaultTFCAddress), "/"),
+		Organization: parsed.Host,
+		Name:         strings.Trim(parsed.Path, "/"),
+		Versions:     100,
+	}
+	if versions := query.Get("versions"); versions != "" {
+		workspace.Versions, err = strconv.Atoi(versions)
+		if err != nil || workspace.Versions < 1 {
+			return nil, fmt.Errorf("invalid versions '%s' (expected a positive number)", versions)
+		}
+	}
+	return workspace, nil
+}
+
+// String returns the workspace for messages
+func (w *TFCWorkspace) String() string {
+	return "tfc://" + w.Organization + "/" + w.Name
+}
+
+// newTFCClient creates an API client for an address, looking up its token
+func newTFCClient(address string) (*TFCClient, error) {
+	parsed, err := url.Parse(address)
+	if err != nil || parsed.Hostname() == "" {
+		return nil, fmt.Errorf("invalid Terraform Cloud address '%s'", address)
+	}
+
+	token, err := tfcToken(parsed.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	return &TFCClient{Address: address, Token: token, Client: &http.Client{Timeout: 2 * time.Minute}}, nil
+}
+
+// tfcToken returns the API token for a host from its TF_TOKEN_<host> environment variable
+// (dots become underscores, dashes double underscores) or the credentials file written
+// by terraform login
+func tfcToken(hostname string) (string, error) {
+	variable := "TF_TOKEN_" + strings.NewReplacer(".", "_", "-", "__").Replace(hostname)
+	if token := os.Getenv(variable); token != "" {
+		return token, nil
+	}
+
+	home, _ := os.UserHomeDir()
+	credentialsFile := filepath.Join(home, ".terraform.d", "credentials.tfrc.json")
+	if data, err := os.ReadFile(credentialsFile); err == nil {
+		var credentials struct {
+			Credentials map[string]struct {
+				Token string `json:"token"`
+			} `json:"credentials"`
+		}
+		if err := json.Unmarshal(data, &credentials); err != nil {
+			return "", fmt.Errorf("parsing %s: %v", credentialsFile, err)
+		}
+		if token := credentials.Credentials[hostname].Token; token != "" {
+			return token, nil
+		}
+	}
+
+	return "", fmt.Errorf("no API token for %s; set %s or run terraform login", hostname, variable)
+}
+
+// get sends an authenticated API request and returns the response body
+func (c *TFCClient) get(requestURL string) ([]byte, error) {
+	request, err := http.NewRequest(http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request URL %s: %v", requestURL, err)
+	}
+	// The token is only sent to the API host, not to download URLs on other hosts
+	if request.URL.Host == mustHost(c.Address) {
+		request.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	request.Header.Set("Content-Type", "application/vnd.api+json")
+
+	response, err := c.Client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("requesting %s: %v", request.URL.Redacted(), err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %v", request.URL.Redacted(), err)
+	}
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		return body, nil
+	case http.StatusUnauthorized:
+		return nil, fmt.Errorf("the API token for %s was rejected", c.Address)
+	case http.StatusNotFound:
+		// The API also answers 404 when the token has no access
+		return nil, fmt.Errorf("%s not found or not accessible with this token", request.URL.Path)
+	default:
+		return nil, fmt.Errorf("requesting %s: unexpected status %s", request.URL.Path, response.Status)
+	}
+}
+
+// mustHost returns the host of an address, or "" if it cannot be parsed
+func mustHost(address string) string {
+	parsed, err := url.Parse(address)
+	if err != nil {
+		return ""
+	}
+	return parsed.Host
+}
+
+// getJSON requests an API path and decodes the JSON:API response
+func (c *TFCClient) getJSON(path string, target interface{}) error {
+	body, err := c.get(c.Address + "/api/v2" + path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("parsing response from %s: %v", path, err)
+	}
+	return nil
+}
+
+// currentStateVersion returns the latest state version of a workspace
+func (c *TFCClient) currentStateVersion(workspace *TFCWorkspace) (TFCStateVersion, error) {
+	var workspaceResponse struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	path := fmt.Sprintf("/organizations/%s/workspaces/%s", url.PathEscape(workspace.Organization), url.PathEscape(workspace.Name))
+	if err := c.getJSON(path, &workspaceResponse); err != nil {
+		return TFCStateVersion{}, fmt.Errorf("looking up %s: %v", workspace, err)
+	}
+
+	var versionResponse struct {
+		Data tfcStateVersionData `json:"data"`
+	}
+	if err := c.getJSON("/workspaces/"+url.PathEscape(workspaceResponse.Data.ID)+"/current-state-version", &versionResponse); err != nil {
+		return TFCStateVersion{}, fmt.Errorf("%s has no current state version: %v", workspace, err)
+	}
+	return versionResponse.Data.stateVersion(), nil
+}
+
+// stateVersions lists up to workspace.Versions state versions of a workspace, newest first
+func (c *TFCClient) stateVersions(workspace *TFCWorkspace) ([]TFCStateVersion, error) {
+	var versions []TFCStateVersion
+	for page := 1; page > 0 && len(versions) < workspace.Versions; {
+		query := url.Values{
+			"filter[organization][name]": {workspace.Organization},
+			"filter[workspace][name]":    {workspace.Name},
+			"page[number]":               {strconv.Itoa(page)},
+			"page[size]":                 {"100"},
+		}
+
+		var response struct {
+			Data []tfcStateVersionData `json:"data"`
+			Meta struct {
+				Pagination struct {
+					NextPage *int `json:"next-page"`
+				} `json:"pagination"`
+			} `json:"meta"`
+		}
+		if err := c.getJSON("/state-versions?"+query.Encode(), &response); err != nil {
+			return nil, err
+		}
+
+		for _, data := range response.Data {
+			if len(versions) < workspace.Versions {
+				versions = append(versions, data.stateVersion())
+			}
+		}
+
+		page = 0
+		if response.Meta.Pagination.NextPage != nil {
+			page = *response.Meta.Pagination.NextPage
+		}
+	}
+	return versions, nil
+}
+
+// download fetches the state of a state version
+func (c *TFCClient) download(version TFCStateVersion) ([]byte, error) {
+	if version.DownloadURL == "" {
+		return nil, fmt.Errorf("state version %s has no downloadable state", version.ID)
+	}
+	// Download URLs may be relative to the API address
+	downloadURL := version.DownloadURL
+	if strings.HasPrefix(downloadURL, "/") {
+		downloadURL = c.Address + downloadURL
+	}
+
+	body, err := c.get(downloadURL)
+	if err != nil {
+		return nil, fmt.Errorf("downloading state version %s: %v", version.ID, err)
+	}
+	return body, nil
+}
+
+// fetchTFCState downloads the current state of a workspace
+func fetchTFCState(input string) ([]byte, error) {
+	workspace, err := parseTFCInput(input)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newTFCClient(workspace.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := client.currentStateVersion(workspace)
+	if err != nil {
+		return nil, err
+	}
+	body, err := client.download(version)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(logOutput, "Fetched state version %s (serial %d) of %s\n", version.ID, version.Serial, workspace)
+	return body, nil
+}
+
+// loadTFCSnapshots reads the state versions of a workspace for the timeline
+func loadTFCSnapshots(input string) ([]Snapshot, error) {
+	workspace, err := parseTFCInput(input)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newTFCClient(workspace.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := client.stateVersions(workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+	for _, version := range versions {
+		body, err := client.download(version)
+		if err != nil {
+			return nil, err
+		}
+		stateData, err := parseStateJSON(body)
+		if err != nil {
+			return nil, fmt.Errorf("state version %s: %v", version.ID, err)
+		}
+		snapshots = append(snapshots, Snapshot{
+			Source:    workspace.String() + "@" + version.ID,
+			Timestamp: version.CreatedAt,
+			State:     stateData,
+		})
+	}
+
+	fmt.Fprintf(logOutput, "Loaded %d state versions of %s\n", len(snapshots), workspace)
+	return snapshots, nil
+}
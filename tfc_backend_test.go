@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testTFCToken = "test-token.atlasv1.secret"
+
+// tfcVersions are the state versions of the mock workspace, newest first
+var tfcVersions = []string{"sv-5", "sv-4", "sv-3", "sv-2", "sv-1"}
+
+// tfcServer is a stand-in for the Terraform Cloud API serving the workspace acme/payments
+type tfcServer struct {
+	*httptest.Server
+	// downloadBase is prefixed to the download URLs; "" makes them relative to the API
+	downloadBase string
+	pages        []int
+}
+
+func newTFCServer(t *testing.T) *tfcServer {
+	t.Helper()
+	logOutput = io.Discard
+
+	server := &tfcServer{}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/v2/organizations/acme/workspaces/payments", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data": {"id": "ws-123", "type": "workspaces", "attributes": {"name": "payments"}}}`)
+	})
+	mux.HandleFunc("/api/v2/workspaces/ws-123/current-state-version", func(w http.ResponseWriter, r *http.Request) {
+		writeTFCJSON(t, w, map[string]interface{}{"data": server.stateVersion(0)})
+	})
+	mux.HandleFunc("/api/v2/state-versions", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("filter[organization][name]") != "acme" || query.Get("filter[workspace][name]") != "payments" {
+			t.Errorf("state versions listed without the workspace filter: %s", r.URL.RawQuery)
+		}
+
+		// Two versions per page, whatever page size the client asks for
+		page, _ := strconv.Atoi(query.Get("page[number]"))
+		server.pages = append(server.pages, page)
+		var data []interface{}
+		for i := (page - 1) * 2; i < page*2 && i < len(tfcVersions); i++ {
+			data = append(data, server.stateVersion(i))
+		}
+		var next interface{}
+		if page*2 < len(tfcVersions) {
+			next = page + 1
+		}
+		writeTFCJSON(t, w, map[string]interface{}{
+			"data": data,
+			"meta": map[string]interface{}{"pagination": map[string]interface{}{"current-page": page, "next-page": next}},
+		})
+	})
+	mux.HandleFunc("/api/state-versions/", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, testState)
+	})
+
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer "+testTFCToken {
+			t.Errorf("API request %s sent Authorization %q", r.URL.Path, got)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("TF_TOKEN_"+strings.ReplaceAll(mustHostname(t, server.URL), ".", "_"), testTFCToken)
+	return server
+}
+
+// stateVersion returns the API representation of the i-th newest state version
+func (s *tfcServer) stateVersion(i int) map[string]interface{} {
+	id := tfcVersions[i]
+	return map[string]interface{}{
+		"id":   id,
+		"type": "state-versions",
+		"attributes": map[string]interface{}{
+			"serial":                    len(tfcVersions) - i,
+			"created-at":                fmt.Sprintf("2026-10-%02dT12:00:00Z", 10+len(tfcVersions)-i),
+			"hosted-state-download-url": s.downloadBase + "/api/state-versions/" + id + "/hosted_state",
+		},
+	}
+}
+
+func writeTFCJSON(t *testing.T, w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		t.Errorf("encoding response: %v", err)
+	}
+}
+
+func mustHostname(t *testing.T, address string) string {
+	parsed, err := url.Parse(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Hostname()
+}
+
+func TestTFCFetchStateRelativeDownloadURL(t *testing.T) {
+	server := newTFCServer(t)
+
+	data, err := fetchTFCState("tfc://acme/payments?address=" + url.QueryEscape(server.URL))
+	if err != nil {
+		t.Fatalf("fetchTFCState: %v", err)
+	}
+	if string(data) != testState {
+		t.Errorf("fetchTFCState returned %q, want %q", data, testState)
+	}
+}
+
+func TestTFCFetchStateForeignDownloadHost(t *testing.T) {
+	server := newTFCServer(t)
+
+	// Archivist-style download host on another origin, which must not see the token
+	downloads := 0
+	archivist := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("download host received Authorization %q", auth)
+		}
+		io.WriteString(w, testState)
+	}))
+	defer archivist.Close()
+	server.downloadBase = archivist.URL
+
+	data, err := fetchTFCState("tfc://acme/payments?address=" + url.QueryEscape(server.URL))
+	if err != nil {
+		t.Fatalf("fetchTFCState: %v", err)
+	}
+	if string(data) != testState || downloads != 1 {
+		t.Errorf("fetchTFCState returned %q after %d downloads, want %q after 1", data, downloads, testState)
+	}
+}
+
+func TestTFCStateVersionsPagination(t *testing.T) {
+	tests := []struct {
+		versions  int
+		wantIDs   string
+		wantPages string
+	}{
+		{versions: 100, wantIDs: "sv-5,sv-4,sv-3,sv-2,sv-1", wantPages: "1,2,3"},
+		{versions: 3, wantIDs: "sv-5,sv-4,sv-3", wantPages: "1,2"},
+		{versions: 2, wantIDs: "sv-5,sv-4", wantPages: "1"},
+	}
+
+	for _, test := range tests {
+		t.Run(strconv.Itoa(test.versions), func(t *testing.T) {
+			server := newTFCServer(t)
+			client := &TFCClient{Address: server.URL, Token: testTFCToken, Client: http.DefaultClient}
+
+			versions, err := client.stateVersions(&TFCWorkspace{Address: server.URL, Organization: "acme", Name: "payments", Versions: test.versions})
+			if err != nil {
+				t.Fatalf("stateVersions: %v", err)
+			}
+
+			var ids, pages []string
+			for _, version := range versions {
+				ids = append(ids, version.ID)
+			}
+			for _, page := range server.pages {
+				pages = append(pages, strconv.Itoa(page))
+			}
+			if got := strings.Join(ids, ","); got != test.wantIDs {
+				t.Errorf("versions = %s, want %s", got, test.wantIDs)
+			}
+			if got := strings.Join(pages, ","); got != test.wantPages {
+				t.Errorf("requested pages %s, want %s", got, test.wantPages)
+			}
+		})
+	}
+}
+
+func TestTFCLoadSnapshots(t *testing.T) {
+	server := newTFCServer(t)
+
+	snapshots, err := loadTFCSnapshots("tfc://acme/payments?versions=3&address=" + url.QueryEscape(server.URL))
+	if err != nil {
+		t.Fatalf("loadTFCSnapshots: %v", err)
+	}
+	if len(snapshots) != 3 {
+		t.Fatalf("loaded %d snapshots, want 3", len(snapshots))
+	}
+	if got, want := snapshots[0].Source, "tfc://acme/payments@sv-5"; got != want {
+		t.Errorf("first snapshot source = %s, want %s", got, want)
+	}
+}
+
+func TestTFCUnknownWorkspace(t *testing.T) {
+	server := newTFCServer(t)
+
+	_, err := fetchTFCState("tfc://acme/unknown?address=" + url.QueryEscape(server.URL))
+	if err == nil || !strings.Contains(err.Error(), "not found or not accessible") {
+		t.Errorf("fetchTFCState error = %v, want a not found error", err)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Built-in HTML themes
+const (
+	themeAuto         = "auto"
+	themeLight        = "light"
+	themeDark         = "dark"
+	themeHighContrast = "high-contrast"
+)
+
+// themePalettes holds the CSS custom properties of each built-in theme
+var themePalettes = map[string]map[string]string{
+	themeLight: {
+		"background":           "#f5f5f5",
+		"surface":              "white",
+		"section-background":   "#ecf0f1",
+		"subtle-background":    "#f8f9fa",
+		"hover-background":     "#f0f0f0",
+		"text":                 "#212529",
+		"heading":              "#2c3e50",
+		"accent":               "#3498db",
+		"muted":                "#7f8c8d",
+		"description":          "#6c757d",
+		"key":                  "#495057",
+		"faint":                "#bdc3c7",
+		"border":               "#e9ecef",
+		"input-border":         "#ccc",
+		"shadow":               "rgba(0,0,0,0.1)",
+		"managed":              "#27ae60",
+		"data":                 "#f39c12",
+		"module":               "#9b59b6",
+		"module-text":          "#8e44ad",
+		"ok":                   "#27ae60",
+		"bad":                  "#c0392b",
+		"warn":                 "#e67e22",
+		"highlight":            "#f1c40f",
+		"sensitive-background": "#fff3cd",
+		"sensitive-border":     "#ffc107",
+	},
+	themeDark: {
+		"background":           "#15191e",
+		"surface":              "#1f252c",
+		"section-background":   "#262d35",
+		"subtle-background":    "#192026",
+		"hover-background":     "#2e3740",
+		"text":                 "#d8dee4",
+		"heading":              "#e6edf3",
+		"accent":               "#58a6ff",
+		"muted":                "#8b949e",
+		"description":          "#9aa4ae",
+		"key":                  "#c9d1d9",
+		"faint":                "#4b5561",
+		"border":               "#30363d",
+		"input-border":         "#484f58",
+		"shadow":               "rgba(0,0,0,0.5)",
+		"managed":              "#3fb950",
+		"data":                 "#d29922",
+		"module":               "#a371f7",
+		"module-text":          "#bc8cff",
+		"ok":                   "#3fb950",
+		"bad":                  "#f85149",
+		"warn":                 "#db6d28",
+		"highlight":            "#e3b341",
+		"sensitive-background": "#3b2f10",
+		"sensitive-border":     "#d29922",
+	},
+	themeHighContrast: {
+		"background":           "black",
+		"surface":              "black",
+		"section-background":   "#0a0a0a",
+		"subtle-background":    "black",
+		"hover-background":     "#333",
+		"text":                 "white",
+		"heading":              "white",
+		"accent":               "#66ccff",
+		"muted":                "#e0e0e0",
+		"description":          "#e0e0e0",
+		"key":                  "yellow",
+		"faint":                "#bbbbbb",
+		"border":               "white",
+		"input-border":         "white",
+		"shadow":               "none",
+		"managed":              "#00ff66",
+		"data":                 "#ffcc00",
+		"module":               "#ff66ff",
+		"module-text":          "#ff99ff",
+		"ok":                   "#00ff66",
+		"bad":                  "#ff5555",
+		"warn":                 "#ffaa00",
+		"highlight":            "yellow",
+		"sensitive-background": "#332b00",
+		"sensitive-border":     "yellow",
+	},
+}
+
+// Branding customizes the title, logo, footer and styling of the HTML page
+type Branding struct {
+	Title string
+	// Logo is an image URL or data URI shown next to the title
+	Logo      string
+	Footer    string
+	CustomCSS string
+	HidePromo bool
+}
+
+// themeNames returns the names accepted by -theme
+func themeNames() []string {
+	names := []string{themeAuto}
+	for name := range themePalettes {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// validateTheme checks that a theme name is known
+func validateTheme(theme string) error {
+	if theme == "" || theme == themeAuto {
+		return nil
+	}
+	if _, ok := themePalettes[theme]; !ok {
+		return fmt.Errorf("unsupported theme '%s' (expected one of: %s)", theme, strings.Join(themeNames(), ", "))
+	}
+	return nil
+}
+
+// themeCss returns the CSS custom properties for a theme; the auto theme follows
+// the browser's prefers-color-scheme setting
+func themeCss(theme string) string {
+	switch theme {
+	case "", themeAuto:
+		return paletteCss(themePalettes[themeLight]) +
+			"\n        @media (prefers-color-scheme: dark) {\n    " + paletteCss(themePalettes[themeDark]) + "\n        }"
+	default:
+		return paletteCss(themePalettes[theme])
+	}
+}
+
+// paletteCss renders a palette as a :root rule
+func paletteCss(palette map[string]string) string {
+	names := make([]string, 0, len(palette))
+	for name := range palette {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var css strings.Builder
+	css.WriteString("        :root {\n")
+	for _, name := range names {
+		css.WriteString(fmt.Sprintf("            --%s: %s;\n", name, palette[name]))
+	}
+	css.WriteString("        }")
+	return css.String()
+}
+
+// loadBranding builds the page branding from flag values, reading the custom CSS
+// file and inlining a local logo file as a data URI so the page stays self-contained
+func loadBranding(title, logo, cssFile, footer string, hidePromo bool) (Branding, error) {
+	branding := Branding{Title: title, Footer: footer, HidePromo: hidePromo}
+
+	if cssFile != "" {
+		data, err := os.ReadFile(cssFile)
+		if err != nil {
+			return Branding{}, fmt.Errorf("failed to read CSS file %s: %v", cssFile, err)
+		}
+		branding.CustomCSS = string(data)
+	}
+
+	switch {
+	case logo == "", isRemoteLogo(logo):
+		branding.Logo = logo
+	default:
+		data, err := os.ReadFile(logo)
+		if err != nil {
+			return Branding{}, fmt.Errorf("failed to read logo %s: %v", logo, err)
+		}
+		mimeType := mime.TypeByExtension(strings.ToLower(filepath.Ext(logo)))
+		if !strings.HasPrefix(mimeType, "image/") {
+			return Branding{}, fmt.Errorf("logo %s is not a recognized image type", logo)
+		}
+		branding.Logo = "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+	}
+
+	return branding, nil
+}
+
+// isRemoteLogo reports whether a logo is a URL rather than a local file
+func isRemoteLogo(logo string) bool {
+	return strings.HasPrefix(logo, "http://") || strings.HasPrefix(logo, "https://") || strings.HasPrefix(logo, "data:")
+}
+
+// pageTitle returns the configured page title or the default
+func (b Branding) pageTitle() string {
+	if b.Title == "" {
+		return "Terraform State"
+	}
+	return b.Title
+}
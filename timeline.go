@@ -0,0 +1,423 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// timelineTemplateName is the template that renders the timeline page
+const timelineTemplateName = "timeline.html.tmpl"
+
+// Snapshot is one state file in a series of snapshots
+type Snapshot struct {
+	Source    string
+	Timestamp time.Time
+	State     *StateData
+	// Commit is set for snapshots read from git history
+	Commit *CommitInfo
+}
+
+// Label identifies the snapshot in the timeline by commit, state serial or file name
+func (s Snapshot) Label() string {
+	if s.Commit != nil {
+		return s.Commit.ShortHash()
+	}
+	if s.State.Serial > 0 {
+		return fmt.Sprintf("serial %d", s.State.Serial)
+	}
+	return filepath.Base(s.Source)
+}
+
+// Description summarizes where the snapshot came from, e.g. for link tooltips
+func (s Snapshot) Description() string {
+	if s.Commit != nil {
+		return fmt.Sprintf("%s %s: %s", s.Commit.ShortHash(), s.Commit.Author, s.Commit.Subject)
+	}
+	return s.Source
+}
+
+// ResourceChange lists the top-level attributes of a resource that changed between snapshots
+type ResourceChange struct {
+	Address    string
+	Attributes []string
+}
+
+// StateDiff describes the differences between two consecutive snapshots
+type StateDiff struct {
+	Added   []string
+	Removed []string
+	Changed []ResourceChange
+	// Outputs lists outputs that were added, removed or changed
+	Outputs []string
+}
+
+// Empty reports whether nothing changed
+func (d StateDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 && len(d.Outputs) == 0
+}
+
+// TimelineStep is a snapshot with the changes since the previous one
+type TimelineStep struct {
+	Index    int
+	Snapshot Snapshot
+	Diff     StateDiff
+}
+
+// TypeTrend counts the resources of a type in each snapshot
+type TypeTrend struct {
+	Type   string
+	Counts []int
+}
+
+// ResourceHistory records the steps in which a resource first appeared, last changed
+// and was destroyed; -1 means never
+type ResourceHistory struct {
+	Address     string
+	Type        string
+	FirstSeen   int
+	LastChanged int
+	Destroyed   int
+}
+
+// Timeline is the history of a state across a series of snapshots
+type Timeline struct {
+	Steps   []TimelineStep
+	Trends  []TypeTrend
+	History []ResourceHistory
+	// Totals counts all resources in each snapshot
+	Totals []int
+}
+
+// loadSnapshots loads the state files matching comma-separated glob patterns and the
+// state versions of tfc:// workspaces
+func loadSnapshots(patterns string) ([]Snapshot, error) {
+	var snapshots []Snapshot
+	seen := make(map[string]bool)
+
+	for _, pattern := range splitList(patterns) {
+		// Terraform Cloud workspaces contribute their state versions
+		if strings.HasPrefix(pattern, "tfc://") {
+			versions, err := loadTFCSnapshots(pattern)
+			if err != nil {
+				return nil, err
+			}
+			snapshots = append(snapshots, versions...)
+			continue
+		}
+
+		// Archives may name an entry, e.g. 'builds/*.tar.gz#terraform.tfstate'
+		pattern, entry := splitArchiveEntry(pattern)
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %v", pattern, err)
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no files match '%s'", pattern)
+		}
+
+		for _, file := range files {
+			source := file
+			if entry != "" {
+				source += "#" + entry
+			}
+			if seen[source] {
+				continue
+			}
+			seen[source] = true
+
+			info, err := os.Stat(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read file %s: %v", file, err)
+			}
+			stateData, err := loadStateFile(source)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", source, err)
+			}
+			snapshots = append(snapshots, Snapshot{Source: source, Timestamp: info.ModTime(), State: stateData})
+		}
+	}
+
+	return snapshots, nil
+}
+
+// sortSnapshots orders snapshots by state serial when every snapshot records one,
+// and by timestamp otherwise
+func sortSnapshots(snapshots []Snapshot) {
+	bySerial := true
+	for _, snapshot := range snapshots {
+		if snapshot.State.Serial == 0 {
+			bySerial = false
+		}
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		a, b := snapshots[i], snapshots[j]
+		if bySerial && a.State.Serial != b.State.Serial {
+			return a.State.Serial < b.State.Serial
+		}
+		if !a.Timestamp.Equal(b.Timestamp) {
+			return a.Timestamp.Before(b.Timestamp)
+		}
+		return a.Source < b.Source
+	})
+}
+
+// diffStates compares two snapshots of a state; before may be nil for the first snapshot
+func diffStates(before, after *StateData) StateDiff {
+	diff := StateDiff{}
+
+	previous := make(map[string]Resource)
+	if before != nil {
+		for _, resource := range before.Resources {
+			previous[resource.Address] = resource
+		}
+	}
+
+	current := make(map[string]bool)
+	for _, resource := range after.Resources {
+		current[resource.Address] = true
+		old, exists := previous[resource.Address]
+		if !exists {
+			diff.Added = append(diff.Added, resource.Address)
+			continue
+		}
+		if attributes := changedAttributes(old.Values, resource.Values); len(attributes) > 0 {
+			diff.Changed = append(diff.Changed, ResourceChange{Address: resource.Address, Attributes: attributes})
+		}
+	}
+	if before != nil {
+		for _, resource := range before.Resources {
+			if !current[resource.Address] {
+				diff.Removed = append(diff.Removed, resource.Address)
+			}
+		}
+	}
+
+	outputs := make(map[string]interface{})
+	if before != nil {
+		for _, output := range before.Outputs {
+			outputs[output.Name] = output.Value
+		}
+	}
+	for _, output := range after.Outputs {
+		if old, exists := outputs[output.Name]; !exists || !reflect.DeepEqual(old, output.Value) {
+			diff.Outputs = append(diff.Outputs, output.Name)
+		}
+		delete(outputs, output.Name)
+	}
+	for name := range outputs {
+		diff.Outputs = append(diff.Outputs, name)
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Outputs)
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Address < diff.Changed[j].Address })
+	return diff
+}
+
+// changedAttributes returns the top-level attributes whose values differ
+func changedAttributes(before, after map[string]interface{}) []string {
+	var changed []string
+	for key, value := range after {
+		if old, exists := before[key]; !exists || !reflect.DeepEqual(old, value) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range before {
+		if _, exists := after[key]; !exists {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// buildTimeline diffs consecutive snapshots and tracks per-type counts and per-resource history
+func buildTimeline(snapshots []Snapshot) *Timeline {
+	timeline := &Timeline{}
+	trends := make(map[string][]int)
+	history := make(map[string]*ResourceHistory)
+
+	var previous *StateData
+	for index, snapshot := range snapshots {
+		diff := diffStates(previous, snapshot.State)
+		timeline.Steps = append(timeline.Steps, TimelineStep{Index: index, Snapshot: snapshot, Diff: diff})
+		timeline.Totals = append(timeline.Totals, len(snapshot.State.Resources))
+
+		for resourceType, count := range snapshot.State.ResourceCounts {
+			if trends[resourceType] == nil {
+				trends[resourceType] = make([]int, len(snapshots))
+			}
+			trends[resourceType][index] = count
+		}
+
+		for _, resource := range snapshot.State.Resources {
+			if _, exists := history[resource.Address]; !exists {
+				history[resource.Address] = &ResourceHistory{Address: resource.Address, Type: resource.Type, FirstSeen: index, LastChanged: index, Destroyed: -1}
+			}
+		}
+		for _, address := range diff.Added {
+			// A resource re-created after being destroyed changes again
+			history[address].LastChanged = index
+			history[address].Destroyed = -1
+		}
+		for _, change := range diff.Changed {
+			history[change.Address].LastChanged = index
+		}
+		for _, address := range diff.Removed {
+			history[address].Destroyed = index
+		}
+
+		previous = snapshot.State
+	}
+
+	for resourceType, counts := range trends {
+		timeline.Trends = append(timeline.Trends, TypeTrend{Type: resourceType, Counts: counts})
+	}
+	sort.Slice(timeline.Trends, func(i, j int) bool { return timeline.Trends[i].Type < timeline.Trends[j].Type })
+
+	for _, entry := range history {
+		timeline.History = append(timeline.History, *entry)
+	}
+	sort.Slice(timeline.History, func(i, j int) bool { return timeline.History[i].Address < timeline.History[j].Address })
+
+	return timeline
+}
+
+// sparklineSvg draws resource counts as a small inline line chart
+func sparklineSvg(counts []int) template.HTML {
+	const width, height = 120, 24
+	if len(counts) == 0 {
+		return ""
+	}
+
+	highest := 1
+	for _, count := range counts {
+		if count > highest {
+			highest = count
+		}
+	}
+
+	points := make([]string, len(counts))
+	for i, count := range counts {
+		x := 0.0
+		if len(counts) > 1 {
+			x = float64(i) * width / float64(len(counts)-1)
+		}
+		y := height - 2 - float64(count)*(height-4)/float64(highest)
+		points[i] = fmt.Sprintf("%.1f,%.1f", x, y)
+	}
+
+	return template.HTML(fmt.Sprintf(`<svg width="%d" height="%d" viewBox="0 0 %d %d" style="overflow: visible;"><polyline points="%s" fill="none" stroke="var(--accent)" stroke-width="2"/></svg>`,
+		width, height, width, height, strings.Join(points, " ")))
+}
+
+// TimelineTemplateData is the data passed to the timeline page template
+type TimelineTemplateData struct {
+	Timeline *Timeline
+	Title    string
+	Theme    string
+}
+
+// generateTimelineHtml renders the timeline page with the configured or default template
+func generateTimelineHtml(timeline *Timeline, options Options) (string, error) {
+	// Templates in a directory are parsed together, so the timeline shares the page helpers
+	funcs := templateFuncs(&StateData{}, options)
+
+	page, err := loadPageTemplate(options.TemplateDir, timelineTemplateName, funcs)
+	if err != nil {
+		return "", err
+	}
+
+	title := options.Branding.Title
+	if title == "" {
+		title = "Terraform State Timeline"
+	}
+	data := TimelineTemplateData{Timeline: timeline, Title: title, Theme: options.Theme}
+
+	var output bytes.Buffer
+	if err := page.ExecuteTemplate(&output, timelineTemplateName, data); err != nil {
+		return "", fmt.Errorf("rendering template: %v", err)
+	}
+	return output.String(), nil
+}
+
+// runTimelineCommand runs the "timeline" subcommand
+func runTimelineCommand(args []string) error {
+	flags := flag.NewFlagSet("timeline", flag.ExitOnError)
+	inputPattern := flags.String("i", "", "State snapshots to compare (comma-separated globs, e.g. 'states/*.json')")
+	gitRepo := flags.String("git-repo", "", "Read the snapshots from the history of a local git repository instead")
+	gitPath := flags.String("git-path", "terraform.tfstate", "State file path inside the git repository")
+	outputFile := flags.String("o", "state-timeline.html", "Output HTML file path")
+	theme := flags.String("theme", themeAuto, "HTML theme: auto, dark, high-contrast, light")
+	title := flags.String("title", "", "Page title (default: Terraform State Timeline)")
+	templateDir := flags.String("template", "", "Directory with custom page templates (see: template export)")
+	configPath := defineSubcommandFlags(flags)
+	flags.Parse(args)
+
+	if (*inputPattern == "") == (*gitRepo == "") {
+		return fmt.Errorf("either -i or -git-repo is required")
+	}
+	if *outputFile == "-" {
+		logOutput = os.Stderr
+	}
+	if err := validateTheme(*theme); err != nil {
+		return err
+	}
+	options, err := loadSubcommandOptions(flags, *configPath)
+	if err != nil {
+		return err
+	}
+
+	var snapshots []Snapshot
+	if *gitRepo != "" {
+		// Commit order is kept so reverts show up as changes
+		snapshots, err = loadGitSnapshots(*gitRepo, *gitPath)
+	} else {
+		snapshots, err = loadSnapshots(*inputPattern)
+		sortSnapshots(snapshots)
+	}
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		return fmt.Errorf("no state snapshots found")
+	}
+	for _, snapshot := range snapshots {
+		if err := prepareState(snapshot.State, options); err != nil {
+			return err
+		}
+	}
+
+	lineages := make(map[string]bool)
+	for _, snapshot := range snapshots {
+		if snapshot.State.Lineage != "" {
+			lineages[snapshot.State.Lineage] = true
+		}
+	}
+	if len(lineages) > 1 {
+		fmt.Fprintf(logOutput, "Warning: snapshots come from %d different state lineages\n", len(lineages))
+	}
+
+	timeline := buildTimeline(snapshots)
+	options.Theme, options.TemplateDir, options.Branding = *theme, *templateDir, Branding{Title: *title}
+	content, err := generateTimelineHtml(timeline, options)
+	if err != nil {
+		return err
+	}
+
+	if err := writeOutputFile(*outputFile, content); err != nil {
+		return fmt.Errorf("writing output file: %v", err)
+	}
+	fmt.Fprintf(logOutput, "Timeline of %d snapshots written to %s\n", len(snapshots), *outputFile)
+	return nil
+}
@@ -0,0 +1,754 @@
+package main
+
+import (
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// Views of the interactive browser
+const (
+	browseViewList    = "list"
+	browseViewDetail  = "detail"
+	browseViewModules = "modules"
+)
+
+// browseKey identifies a key press decoded from terminal input
+type browseKey int
+
+const (
+	keyRune browseKey = iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyEnter
+	keyEscape
+	keyBackspace
+	keyCtrlC
+)
+
+// keyEvent is a single decoded key press
+type keyEvent struct {
+	Key  browseKey
+	Rune rune
+}
+
+// browseLine is a selectable line in the detail or modules view
+type browseLine struct {
+	Text       string
+	Depth      int
+	Path       string
+	Expandable bool
+	Header     bool
+	Sensitive  bool
+	Target     string
+	Module     string
+}
+
+// browser holds the state of the interactive state browser
+type browser struct {
+	state       *StateData
+	view        string
+	query       string
+	searching   bool
+	module      string
+	filtered    []int
+	cursor      int
+	offset      int
+	current     int
+	history     []int
+	expanded    map[string]bool
+	lineCursor  int
+	lineOffset  int
+	moduleLines []browseLine
+	status      string
+	clipboard   io.Writer
+	// dependents maps each resource address to the resources that depend on it
+	dependents map[string][]string
+}
+
+// runBrowseCommand runs the "browse" subcommand
+func runBrowseCommand(args []string) error {
+	flags := flag.NewFlagSet("browse", flag.ExitOnError)
+	inputFile := flags.String("i", "", "Input file path (required)")
+	configPath := defineSubcommandFlags(flags)
+	flags.Parse(args)
+
+	if err := validateInput(*inputFile); err != nil {
+		return err
+	}
+	options, err := loadSubcommandOptions(flags, *configPath)
+	if err != nil {
+		return err
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return fmt.Errorf("browse requires an interactive terminal")
+	}
+
+	// Progress messages would corrupt the full-screen view
+	logOutput = io.Discard
+	stateData, err := loadSubcommandState(*inputFile, options)
+	if err != nil {
+		return err
+	}
+
+	return runBrowser(newBrowser(stateData, os.Stdout))
+}
+
+// newBrowser creates a browser showing every resource in the state
+func newBrowser(stateData *StateData, clipboard io.Writer) *browser {
+	b := &browser{
+		state:     stateData,
+		view:      browseViewList,
+		expanded:  make(map[string]bool),
+		clipboard: clipboard,
+	}
+	b.dependents = buildDependents(stateData)
+	b.applyFilter()
+	return b
+}
+
+// buildDependents resolves the dependencies of every resource once, so the detail view can
+// list the resources requiring a resource without rescanning the state on each render
+func buildDependents(stateData *StateData) map[string][]string {
+	dependents := make(map[string][]string)
+	for _, resource := range stateData.Resources {
+		for _, dep := range resource.DependsOn {
+			for _, target := range resolveDependency(stateData, dep) {
+				dependents[target] = append(dependents[target], resource.Address)
+			}
+		}
+	}
+	return dependents
+}
+
+// runBrowser puts the terminal in raw mode and runs the input loop until the user quits
+func runBrowser(b *browser) error {
+	fd := int(os.Stdin.Fd())
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("enabling raw terminal mode: %v", err)
+	}
+	defer term.Restore(fd, oldState)
+
+	// Switch to the alternate screen and hide the cursor while browsing
+	fmt.Fprint(os.Stdout, "\033[?1049h\033[?25l")
+	defer fmt.Fprint(os.Stdout, "\033[?25h\033[?1049l")
+
+	buf := make([]byte, 64)
+	var pending []byte
+	for {
+		width, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			width, height = 80, 24
+		}
+		fmt.Fprint(os.Stdout, "\033[H\033[2J"+strings.Join(b.render(width, height), "\r\n"))
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return err
+		}
+		var events []keyEvent
+		events, pending = decodeKeys(append(pending, buf[:n]...))
+		for _, event := range events {
+			if b.handleKey(event) {
+				return nil
+			}
+		}
+	}
+}
+
+// decodeKeys converts raw terminal input into key events. A character split across reads is
+// returned as the remainder to prepend to the next read; invalid bytes are skipped.
+func decodeKeys(input []byte) ([]keyEvent, []byte) {
+	var events []keyEvent
+
+	sequences := map[string]browseKey{
+		"\033[A": keyUp, "\033[B": keyDown, "\033[C": keyRight, "\033[D": keyLeft,
+		"\033OA": keyUp, "\033OB": keyDown, "\033OC": keyRight, "\033OD": keyLeft,
+		"\033[5~": keyPageUp, "\033[6~": keyPageDown,
+		"\033[H": keyHome, "\033[F": keyEnd, "\033[1~": keyHome, "\033[4~": keyEnd,
+	}
+
+	for len(input) > 0 {
+		if input[0] == '\033' {
+			matched := false
+			for sequence, key := range sequences {
+				if strings.HasPrefix(string(input), sequence) {
+					events = append(events, keyEvent{Key: key})
+					input = input[len(sequence):]
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				events = append(events, keyEvent{Key: keyEscape})
+				input = input[1:]
+			}
+			continue
+		}
+
+		switch input[0] {
+		case '\r', '\n':
+			events = append(events, keyEvent{Key: keyEnter})
+		case 127, 8:
+			events = append(events, keyEvent{Key: keyBackspace})
+		case 3:
+			events = append(events, keyEvent{Key: keyCtrlC})
+		default:
+			if !utf8.FullRune(input) {
+				return events, append([]byte(nil), input...)
+			}
+			r, size := utf8.DecodeRune(input)
+			if r != utf8.RuneError || size > 1 {
+				events = append(events, keyEvent{Key: keyRune, Rune: r})
+			}
+			input = input[size:]
+			continue
+		}
+		input = input[1:]
+	}
+
+	return events, nil
+}
+
+// handleKey applies a key press to the browser state and reports whether to quit
+func (b *browser) handleKey(event keyEvent) bool {
+	b.status = ""
+
+	if event.Key == keyCtrlC {
+		return true
+	}
+
+	if b.searching {
+		switch event.Key {
+		case keyEnter, keyEscape:
+			b.searching = false
+		case keyBackspace:
+			if len(b.query) > 0 {
+				runes := []rune(b.query)
+				b.query = string(runes[:len(runes)-1])
+				b.applyFilter()
+			}
+		case keyRune:
+			b.query += string(event.Rune)
+			b.applyFilter()
+		case keyUp, keyDown, keyPageUp, keyPageDown:
+			b.moveCursor(event.Key)
+		}
+		return false
+	}
+
+	if event.Key == keyRune && event.Rune == 'q' {
+		return true
+	}
+
+	switch b.view {
+	case browseViewList:
+		return b.handleListKey(event)
+	case browseViewDetail:
+		b.handleDetailKey(event)
+	case browseViewModules:
+		b.handleModulesKey(event)
+	}
+	return false
+}
+
+// handleListKey handles key presses in the resource list view
+func (b *browser) handleListKey(event keyEvent) bool {
+	switch event.Key {
+	case keyUp, keyDown, keyPageUp, keyPageDown, keyHome, keyEnd:
+		b.moveCursor(event.Key)
+	case keyEnter, keyRight:
+		if len(b.filtered) > 0 {
+			b.history = nil
+			b.openResource(b.filtered[b.cursor])
+		}
+	case keyEscape:
+		b.query = ""
+		b.module = ""
+		b.applyFilter()
+	case keyRune:
+		switch event.Rune {
+		case '/':
+			b.searching = true
+		case 'j':
+			b.moveCursor(keyDown)
+		case 'k':
+			b.moveCursor(keyUp)
+		case 'm':
+			b.openModules()
+		case 'y':
+			if len(b.filtered) > 0 {
+				b.copyAddress(b.state.Resources[b.filtered[b.cursor]].Address)
+			}
+		}
+	}
+	return false
+}
+
+// handleDetailKey handles key presses in the resource detail view
+func (b *browser) handleDetailKey(event keyEvent) {
+	lines := b.detailLines()
+
+	switch event.Key {
+	case keyUp, keyDown, keyPageUp, keyPageDown, keyHome, keyEnd:
+		b.moveLineCursor(event.Key, len(lines))
+	case keyEnter, keyRight:
+		if b.lineCursor >= len(lines) {
+			return
+		}
+		line := lines[b.lineCursor]
+		switch {
+		case line.Target != "":
+			b.jumpTo(line.Target)
+		case line.Expandable:
+			b.expanded[line.Path] = !b.expanded[line.Path]
+		}
+	case keyEscape, keyBackspace, keyLeft:
+		b.back()
+	case keyRune:
+		switch event.Rune {
+		case 'j':
+			b.moveLineCursor(keyDown, len(lines))
+		case 'k':
+			b.moveLineCursor(keyUp, len(lines))
+		case ' ':
+			if b.lineCursor < len(lines) && lines[b.lineCursor].Expandable {
+				b.expanded[lines[b.lineCursor].Path] = !b.expanded[lines[b.lineCursor].Path]
+			}
+		case 'h':
+			b.back()
+		case 'm':
+			b.openModules()
+		case 'y':
+			b.copyAddress(b.state.Resources[b.current].Address)
+		}
+	}
+}
+
+// handleModulesKey handles key presses in the module hierarchy view
+func (b *browser) handleModulesKey(event keyEvent) {
+	switch event.Key {
+	case keyUp, keyDown, keyPageUp, keyPageDown, keyHome, keyEnd:
+		b.moveLineCursor(event.Key, len(b.moduleLines))
+	case keyEnter, keyRight:
+		if b.lineCursor < len(b.moduleLines) {
+			b.module = b.moduleLines[b.lineCursor].Module
+			b.query = ""
+			b.view = browseViewList
+			b.applyFilter()
+		}
+	case keyEscape, keyBackspace, keyLeft:
+		b.view = browseViewList
+	case keyRune:
+		switch event.Rune {
+		case 'j':
+			b.moveLineCursor(keyDown, len(b.moduleLines))
+		case 'k':
+			b.moveLineCursor(keyUp, len(b.moduleLines))
+		case 'h':
+			b.view = browseViewList
+		case 'y':
+			if b.lineCursor < len(b.moduleLines) && b.moduleLines[b.lineCursor].Module != "" {
+				b.copyAddress(b.moduleLines[b.lineCursor].Module)
+			}
+		}
+	}
+}
+
+// applyFilter recomputes the visible resources from the search query and module filter
+func (b *browser) applyFilter() {
+	type match struct {
+		index int
+		score int
+	}
+	var matches []match
+
+	for i, resource := range b.state.Resources {
+		switch {
+		case b.module == moduleNodeID(""):
+			if resource.ModuleAddress != "" {
+				continue
+			}
+		case b.module != "":
+			if resource.ModuleAddress != b.module && !strings.HasPrefix(resource.ModuleAddress, b.module+".") {
+				continue
+			}
+		}
+		score, ok := fuzzyMatch(b.query, resource.Address)
+		if !ok {
+			continue
+		}
+		matches = append(matches, match{index: i, score: score})
+	}
+
+	// Best matches first, keeping state order for equal scores
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	b.filtered = b.filtered[:0]
+	for _, m := range matches {
+		b.filtered = append(b.filtered, m.index)
+	}
+	b.cursor = 0
+	b.offset = 0
+}
+
+// fuzzyMatch checks whether all query characters appear in order in the target
+// and scores consecutive and early matches higher
+func fuzzyMatch(query, target string) (int, bool) {
+	if query == "" {
+		return 0, true
+	}
+
+	query = strings.ToLower(query)
+	target = strings.ToLower(target)
+
+	score := 0
+	position := 0
+	previous := -2
+	for _, q := range query {
+		found := strings.IndexRune(target[position:], q)
+		if found < 0 {
+			return 0, false
+		}
+		index := position + found
+		if index == previous+1 {
+			score += 5
+		}
+		if index == 0 || !unicode.IsLetter(rune(target[index-1])) {
+			score += 3
+		}
+		score -= found
+		previous = index
+		position = index + len(string(q))
+	}
+
+	return score, true
+}
+
+// openResource shows the detail view for a resource
+func (b *browser) openResource(index int) {
+	b.current = index
+	b.view = browseViewDetail
+	b.lineCursor = 0
+	b.lineOffset = 0
+}
+
+// jumpTo follows a dependency edge to another resource, remembering where we came from
+func (b *browser) jumpTo(address string) {
+	for i, resource := range b.state.Resources {
+		if resource.Address == address {
+			b.history = append(b.history, b.current)
+			b.openResource(i)
+			return
+		}
+	}
+	b.status = fmt.Sprintf("%s is not in this state", address)
+}
+
+// back returns to the previous resource or to the list view
+func (b *browser) back() {
+	if len(b.history) > 0 {
+		previous := b.history[len(b.history)-1]
+		b.history = b.history[:len(b.history)-1]
+		b.openResource(previous)
+		return
+	}
+	b.view = browseViewList
+}
+
+// openModules shows the module hierarchy view
+func (b *browser) openModules() {
+	b.moduleLines = []browseLine{{
+		Text:   fmt.Sprintf("%s (%d resources)", moduleDisplayName(""), len(b.state.RootModule.Resources)),
+		Module: moduleNodeID(""),
+	}}
+	walkModulesWithDepth(b.state.RootModule.ChildModules, 1, func(module Module, depth int) {
+		b.moduleLines = append(b.moduleLines, browseLine{
+			Text:   fmt.Sprintf("%s (%d resources)", module.Address, countModuleResources(module)),
+			Depth:  depth,
+			Module: module.Address,
+		})
+	})
+
+	b.view = browseViewModules
+	b.lineCursor = 0
+	b.lineOffset = 0
+}
+
+// walkModulesWithDepth calls fn for every module in the tree along with its nesting depth
+func walkModulesWithDepth(modules []Module, depth int, fn func(module Module, depth int)) {
+	for _, module := range modules {
+		fn(module, depth)
+		walkModulesWithDepth(module.ChildModules, depth+1, fn)
+	}
+}
+
+// copyAddress copies text to the system clipboard using the OSC 52 escape sequence,
+// which terminals and tmux forward even over SSH
+func (b *browser) copyAddress(address string) {
+	fmt.Fprintf(b.clipboard, "\033]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(address)))
+	b.status = "Copied " + address + " to clipboard"
+}
+
+// moveCursor moves the selection in the resource list
+func (b *browser) moveCursor(key browseKey) {
+	b.cursor = movePosition(b.cursor, len(b.filtered), key)
+}
+
+// moveLineCursor moves the selection in the detail or modules view
+func (b *browser) moveLineCursor(key browseKey, count int) {
+	b.lineCursor = movePosition(b.lineCursor, count, key)
+}
+
+// movePosition applies a navigation key to a cursor position within count entries
+func movePosition(position, count int, key browseKey) int {
+	const pageSize = 10
+
+	switch key {
+	case keyUp:
+		position--
+	case keyDown:
+		position++
+	case keyPageUp:
+		position -= pageSize
+	case keyPageDown:
+		position += pageSize
+	case keyHome:
+		position = 0
+	case keyEnd:
+		position = count - 1
+	}
+
+	if position >= count {
+		position = count - 1
+	}
+	if position < 0 {
+		position = 0
+	}
+	return position
+}
+
+// detailLines builds the lines of the detail view for the current resource
+func (b *browser) detailLines() []browseLine {
+	resource := b.state.Resources[b.current]
+	var lines []browseLine
+
+	lines = append(lines,
+		browseLine{Text: "Type: " + resource.Type},
+		browseLine{Text: "Mode: " + formatResourceMode(resource.Mode)},
+		browseLine{Text: "Provider: " + resource.ProviderName},
+		browseLine{Text: "Module: " + moduleDisplayName(resource.ModuleAddress)},
+		browseLine{Text: fmt.Sprintf("Schema Version: %d", resource.SchemaVersion)},
+		browseLine{Text: "Values", Header: true},
+	)
+
+	lines = append(lines, b.valueLines(resource.Values, resource.SensitiveValues, resource.Address, 1)...)
+
+	if len(resource.DependsOn) > 0 {
+		lines = append(lines, browseLine{Text: "Depends on", Header: true})
+		for _, dep := range resource.DependsOn {
+			targets := resolveDependency(b.state, dep)
+			if len(targets) == 0 {
+				lines = append(lines, browseLine{Text: dep, Depth: 1})
+			}
+			for _, target := range targets {
+				lines = append(lines, browseLine{Text: "→ " + target, Depth: 1, Target: target})
+			}
+		}
+	}
+
+	if dependents := b.dependents[resource.Address]; len(dependents) > 0 {
+		lines = append(lines, browseLine{Text: "Required by", Header: true})
+		for _, dependent := range dependents {
+			lines = append(lines, browseLine{Text: "← " + dependent, Depth: 1, Target: dependent})
+		}
+	}
+
+	return lines
+}
+
+// valueLines flattens a values tree into lines, descending into expanded entries
+func (b *browser) valueLines(values map[string]interface{}, sensitiveValues map[string]interface{}, path string, depth int) []browseLine {
+	var lines []browseLine
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		sensitive := isSensitiveEntry(key, values[key], sensitiveValues[key])
+		lines = append(lines, b.valueLine(key, values[key], sensitive, sensitiveValues[key], path+"."+key, depth)...)
+	}
+
+	return lines
+}
+
+// valueLine returns the line for a single value and, when expanded, its children. marker is
+// the value's entry in sensitive_values, which marks sensitive values further down.
+func (b *browser) valueLine(key string, value interface{}, sensitive bool, marker interface{}, path string, depth int) []browseLine {
+	if sensitive {
+		return []browseLine{{Text: "  " + key + ": " + maskSensitiveValue(value), Depth: depth, Path: path, Sensitive: true}}
+	}
+
+	line := browseLine{Depth: depth, Path: path}
+	var children []browseLine
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		line.Expandable = len(v) > 0
+		if b.expanded[path] {
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				child := sensitiveChild(marker, key)
+				children = append(children, b.valueLine(key, v[key], isSensitiveEntry(key, v[key], child), child, path+"."+key, depth+1)...)
+			}
+		}
+	case []interface{}:
+		line.Expandable = len(v) > 0
+		if b.expanded[path] {
+			for i, item := range v {
+				child := sensitiveChild(marker, i)
+				children = append(children, b.valueLine(fmt.Sprintf("[%d]", i), item, isSensitiveEntry("", item, child), child, fmt.Sprintf("%s[%d]", path, i), depth+1)...)
+			}
+		}
+	}
+
+	expander := "  "
+	if line.Expandable {
+		expander = "▶ "
+		if b.expanded[path] {
+			expander = "▼ "
+		}
+	}
+	line.Text = expander + key + ": " + formatValue(maskSensitiveTree(value, marker))
+
+	return append([]browseLine{line}, children...)
+}
+
+// render draws the current view into lines that fit the terminal size
+func (b *browser) render(width, height int) []string {
+	var lines []string
+	bodyHeight := height - 3
+	if bodyHeight < 1 {
+		bodyHeight = 1
+	}
+
+	switch b.view {
+	case browseViewList:
+		title := fmt.Sprintf("Resources (%d of %d)", len(b.filtered), len(b.state.Resources))
+		switch {
+		case b.module == moduleNodeID(""):
+			title += "  module: " + moduleDisplayName("")
+		case b.module != "":
+			title += "  module: " + b.module
+		}
+		lines = append(lines, colorize(truncateLine(title, width), ansiBold, true))
+
+		b.offset = scrollOffset(b.cursor, b.offset, bodyHeight)
+		for i := b.offset; i < len(b.filtered) && i < b.offset+bodyHeight; i++ {
+			resource := b.state.Resources[b.filtered[i]]
+			color := ansiGreen
+			if resource.Mode == "data" {
+				color = ansiYellow
+			}
+			lines = append(lines, renderBrowseRow(resource.Address, color, i == b.cursor, width))
+		}
+
+	case browseViewDetail:
+		resource := b.state.Resources[b.current]
+		detail := b.detailLines()
+		lines = append(lines, colorize(truncateLine(resource.Address, width), ansiBold, true))
+
+		b.lineOffset = scrollOffset(b.lineCursor, b.lineOffset, bodyHeight)
+		for i := b.lineOffset; i < len(detail) && i < b.lineOffset+bodyHeight; i++ {
+			line := detail[i]
+			color := ""
+			switch {
+			case line.Header:
+				color = ansiBlue + ansiBold
+			case line.Sensitive:
+				color = ansiYellow
+			case line.Target != "":
+				color = ansiMagenta
+			}
+			lines = append(lines, renderBrowseRow(strings.Repeat("  ", line.Depth)+line.Text, color, i == b.lineCursor, width))
+		}
+
+	case browseViewModules:
+		lines = append(lines, colorize(truncateLine("Modules", width), ansiBold, true))
+
+		b.lineOffset = scrollOffset(b.lineCursor, b.lineOffset, bodyHeight)
+		for i := b.lineOffset; i < len(b.moduleLines) && i < b.lineOffset+bodyHeight; i++ {
+			line := b.moduleLines[i]
+			lines = append(lines, renderBrowseRow(strings.Repeat("  ", line.Depth)+line.Text, ansiMagenta, i == b.lineCursor, width))
+		}
+	}
+
+	for len(lines) < height-2 {
+		lines = append(lines, "")
+	}
+
+	// Status line and key help
+	status := b.status
+	if status == "" && (b.searching || b.query != "") {
+		status = "/" + b.query
+		if b.searching {
+			status += "█"
+		}
+	}
+	lines = append(lines, truncateLine(status, width))
+
+	help := map[string]string{
+		browseViewList:    "↑↓ move  enter open  / search  m modules  y copy address  esc clear  q quit",
+		browseViewDetail:  "↑↓ move  enter expand/follow  esc back  m modules  y copy address  q quit",
+		browseViewModules: "↑↓ move  enter show resources  esc back  y copy address  q quit",
+	}
+	lines = append(lines, colorize(truncateLine(help[b.view], width), ansiDim, true))
+
+	return lines
+}
+
+// renderBrowseRow renders a row of a view, highlighting the selected row
+func renderBrowseRow(text, color string, selected bool, width int) string {
+	text = truncateLine(text, width)
+	if selected {
+		return "\033[7m" + text + strings.Repeat(" ", max(0, width-visibleLength(text))) + ansiReset
+	}
+	if color != "" {
+		return colorize(text, color, true)
+	}
+	return text
+}
+
+// scrollOffset keeps the cursor within the visible window
+func scrollOffset(cursor, offset, height int) int {
+	if cursor < offset {
+		return cursor
+	}
+	if cursor >= offset+height {
+		return cursor - height + 1
+	}
+	return offset
+}
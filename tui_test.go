@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestDecodeKeys(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      []keyEvent
+		remainder string
+	}{
+		{name: "arrows", input: "\033[A\033OB", want: []keyEvent{{Key: keyUp}, {Key: keyDown}}},
+		{name: "runes", input: "aé", want: []keyEvent{{Key: keyRune, Rune: 'a'}, {Key: keyRune, Rune: 'é'}}},
+		{name: "invalid byte", input: "a\xff", want: []keyEvent{{Key: keyRune, Rune: 'a'}}},
+		{name: "split character", input: "a\xe2\x82", want: []keyEvent{{Key: keyRune, Rune: 'a'}}, remainder: "\xe2\x82"},
+		{name: "lone escape", input: "\033", want: []keyEvent{{Key: keyEscape}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			events, remainder := decodeKeys([]byte(test.input))
+			if len(events) != len(test.want) {
+				t.Fatalf("decodeKeys(%q) = %v, want %v", test.input, events, test.want)
+			}
+			for i := range events {
+				if events[i] != test.want[i] {
+					t.Errorf("event %d = %v, want %v", i, events[i], test.want[i])
+				}
+			}
+			if string(remainder) != test.remainder {
+				t.Errorf("remainder = %q, want %q", remainder, test.remainder)
+			}
+		})
+	}
+
+	// The rest of a split character completes it on the next read
+	events, remainder := decodeKeys(append([]byte("\xe2\x82"), 0xac))
+	if len(events) != 1 || events[0].Rune != '€' || len(remainder) != 0 {
+		t.Errorf("completed character decoded as %v with remainder %q, want €", events, remainder)
+	}
+}
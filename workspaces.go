@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// workspacesTemplateName is the comparison page rendered for inputs with several workspaces
+const workspacesTemplateName = "workspaces.html.tmpl"
+
+// WorkspaceLink is an entry of the workspace switcher
+type WorkspaceLink struct {
+	Name    string
+	Href    string
+	Current bool
+}
+
+// WorkspaceState is the parsed state of one workspace
+type WorkspaceState struct {
+	Name  string
+	Href  string
+	State *StateData
+	// Only lists the resources no other workspace has
+	Only []string
+}
+
+// PresenceRow records which workspaces contain a resource
+type PresenceRow struct {
+	Address string
+	Present []bool
+}
+
+// TypeCountRow holds the number of resources of a type in each workspace
+type TypeCountRow struct {
+	Type    string
+	Counts  []int
+	Differs bool
+}
+
+// WorkspaceComparison compares the resources of several workspaces
+type WorkspaceComparison struct {
+	Workspaces []WorkspaceState
+	// Matrix lists the resources missing from at least one workspace
+	Matrix []PresenceRow
+	// Common counts the resources every workspace has
+	Common     int
+	TypeCounts []TypeCountRow
+}
+
+// WorkspacesTemplateData is the data passed to the comparison page template
+type WorkspacesTemplateData struct {
+	Comparison *WorkspaceComparison
+	Workspaces []WorkspaceLink
+	Title      string
+	Theme      string
+}
+
+// OnlyCount returns the number of resources only one workspace has
+func (c *WorkspaceComparison) OnlyCount() int {
+	count := 0
+	for _, workspace := range c.Workspaces {
+		count += len(workspace.Only)
+	}
+	return count
+}
+
+// compareWorkspaces builds the comparison of the given workspace states
+func compareWorkspaces(workspaces []WorkspaceState) *WorkspaceComparison {
+	comparison := &WorkspaceComparison{Workspaces: workspaces}
+
+	presence := make(map[string][]bool)
+	counts := make(map[string][]int)
+	for i, workspace := range workspaces {
+		for _, resource := range workspace.State.Resources {
+			if presence[resource.Address] == nil {
+				presence[resource.Address] = make([]bool, len(workspaces))
+			}
+			presence[resource.Address][i] = true
+
+			if counts[resource.Type] == nil {
+				counts[resource.Type] = make([]int, len(workspaces))
+			}
+			counts[resource.Type][i]++
+		}
+	}
+
+	addresses := make([]string, 0, len(presence))
+	for address := range presence {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	for _, address := range addresses {
+		present := presence[address]
+		found := 0
+		last := -1
+		for i, in := range present {
+			if in {
+				found++
+				last = i
+			}
+		}
+
+		switch found {
+		case len(workspaces):
+			comparison.Common++
+			continue
+		case 1:
+			comparison.Workspaces[last].Only = append(comparison.Workspaces[last].Only, address)
+		}
+		comparison.Matrix = append(comparison.Matrix, PresenceRow{Address: address, Present: present})
+	}
+
+	types := make([]string, 0, len(counts))
+	for resourceType := range counts {
+		types = append(types, resourceType)
+	}
+	sort.Strings(types)
+
+	for _, resourceType := range types {
+		row := TypeCountRow{Type: resourceType, Counts: counts[resourceType]}
+		for _, count := range row.Counts {
+			if count != row.Counts[0] {
+				row.Differs = true
+			}
+		}
+		comparison.TypeCounts = append(comparison.TypeCounts, row)
+	}
+
+	return comparison
+}
+
+// workspaceLinks returns the workspace switcher with the named entry marked as current;
+// the first entry is the comparison page
+func workspaceLinks(comparisonHref string, workspaces []WorkspaceState, current string) []WorkspaceLink {
+	links := []WorkspaceLink{{Name: "All workspaces", Href: comparisonHref, Current: current == ""}}
+	for _, workspace := range workspaces {
+		links = append(links, WorkspaceLink{Name: workspace.Name, Href: workspace.Href, Current: workspace.Name == current})
+	}
+	return links
+}
+
+// generateWorkspacesHtml renders the workspace comparison page with the configured or default template
+func generateWorkspacesHtml(comparison *WorkspaceComparison, links []WorkspaceLink, options Options) (string, error) {
+	// Templates in a directory are parsed together, so the comparison shares the page helpers
+	funcs := templateFuncs(&StateData{}, options)
+
+	page, err := loadPageTemplate(options.TemplateDir, workspacesTemplateName, funcs)
+	if err != nil {
+		return "", err
+	}
+
+	title := options.Branding.Title
+	if title == "" {
+		title = "Terraform Workspaces"
+	}
+	data := WorkspacesTemplateData{Comparison: comparison, Workspaces: links, Title: title, Theme: options.Theme}
+
+	var output bytes.Buffer
+	if err := page.ExecuteTemplate(&output, workspacesTemplateName, data); err != nil {
+		return "", fmt.Errorf("rendering template: %v", err)
+	}
+	return output.String(), nil
+}
+
+// processWorkspaces renders each workspace of a multi-workspace input to its own file, e.g.
+// state-staging.html. For HTML, outputFile becomes a comparison page, and every page gets a
+// workspace switcher linking them.
+func processWorkspaces(inputs []WorkspaceInput, outputFile string, options Options) error {
+	var workspaces []WorkspaceState
+	for _, input := range inputs {
+		fmt.Fprintf(logOutput, "\nLoading workspace %s from %s\n", input.Workspace, displayInput(input.Input))
+
+		parsedState, err := loadStateFile(input.Input)
+		if err != nil {
+			return fmt.Errorf("workspace %s: %v", input.Workspace, err)
+		}
+		if err := prepareState(parsedState, options); err != nil {
+			return err
+		}
+
+		workspaces = append(workspaces, WorkspaceState{
+			Name:  input.Workspace,
+			Href:  filepath.Base(workspaceOutputPath(outputFile, input.Workspace)),
+			State: parsedState,
+		})
+	}
+
+	// Pages written to stdout cannot link to each other
+	withComparison := options.Format == formatHTML && outputFile != "-"
+
+	fmt.Fprintln(logOutput, "\nWriting workspaces:")
+	for _, workspace := range workspaces {
+		workspaceOptions := options
+		if withComparison {
+			workspaceOptions.Workspaces = workspaceLinks(filepath.Base(outputFile), workspaces, workspace.Name)
+		}
+		if err := writeStateFile(workspace.State, workspaceOutputPath(outputFile, workspace.Name), workspaceOptions); err != nil {
+			return fmt.Errorf("workspace %s: %v", workspace.Name, err)
+		}
+	}
+
+	if withComparison {
+		comparison := compareWorkspaces(workspaces)
+		content, err := generateWorkspacesHtml(comparison, workspaceLinks(filepath.Base(outputFile), workspaces, ""), options)
+		if err != nil {
+			return err
+		}
+		if err := writeOutputFile(outputFile, content); err != nil {
+			return fmt.Errorf("writing output file: %v", err)
+		}
+		fmt.Fprintf(logOutput, "Comparison of %d workspaces written to %s\n", len(workspaces), outputFile)
+	}
+
+	fmt.Fprintln(logOutput, "\nFile processing completed!")
+	return nil
+}